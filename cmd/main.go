@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -14,18 +15,32 @@ import (
 	_ "test_task/docs"
 	your_api "test_task/internal/clients/your-api"
 	"test_task/internal/config"
+	"test_task/internal/enrich"
 	"test_task/internal/http-server/handlers"
+	"test_task/internal/http-server/middleware/auth"
 	"test_task/internal/http-server/middleware/cors"
+	"test_task/internal/http-server/middleware/idempotency"
 	"test_task/internal/http-server/middleware/logger"
 	"test_task/internal/lib/l"
+	"test_task/internal/linkcheck"
+	"test_task/internal/metadata"
+	"test_task/internal/refresh"
 	"test_task/internal/storage/psql"
 	"test_task/pkg/e"
+	"test_task/pkg/jwt"
+	"test_task/pkg/validate"
 	"time"
 )
 
 // @title           Music Library API
 // @version         1.0.0
 // @description     API for managing a music library
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 func main() {
 
 	cfg, err := config.LoadEnvConfig("config.env")
@@ -40,9 +55,102 @@ func main() {
 		panic(err)
 	}
 
-	yourApiClient := your_api.NewClient(cfg.YourAPIHost)
+	recordMode, err := your_api.ParseRecordMode(cfg.YourAPIRecordMode)
+	if err != nil {
+		panic(err)
+	}
+
+	var mapping *your_api.Mapping
+
+	if cfg.YourAPIMappingFile != "" {
+		m, err := your_api.LoadMapping(cfg.YourAPIMappingFile)
+		if err != nil {
+			panic(err)
+		}
+
+		mapping = &m
+	}
+
+	yourApiClient, err := your_api.NewClient(cfg.YourAPIURL, your_api.Options{
+		APIKey:           cfg.YourAPIKey,
+		APIKeyHeader:     cfg.YourAPIKeyHeader,
+		BearerToken:      cfg.YourAPIBearerToken,
+		CAFile:           cfg.YourAPICAFile,
+		CertFile:         cfg.YourAPIClientCertFile,
+		KeyFile:          cfg.YourAPIClientKeyFile,
+		Timeout:          cfg.YourAPITimeout,
+		MaxIdleConns:     cfg.YourAPIMaxIdleConns,
+		MaxRetries:       cfg.YourAPIMaxRetries,
+		BackoffBase:      cfg.YourAPIBackoffBase,
+		BackoffMax:       cfg.YourAPIBackoffMax,
+		BreakerThreshold: cfg.YourAPIBreakerThreshold,
+		BreakerCooldown:  cfg.YourAPIBreakerCooldown,
+		RateLimit:        cfg.YourAPIRateLimit,
+		RateBurst:        cfg.YourAPIRateBurst,
+		MaxInFlight:      cfg.YourAPIMaxInFlight,
+		CacheSize:        cfg.YourAPICacheSize,
+		CacheTTL:         cfg.YourAPICacheTTL,
+		CacheNegativeTTL: cfg.YourAPICacheNegativeTTL,
+		Mapping:          mapping,
+		RecordMode:       recordMode,
+		RecordDir:        cfg.YourAPIRecordDir,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	metaProvider, err := newMetadataProvider(cfg, log, yourApiClient)
+	if err != nil {
+		panic(err)
+	}
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	enrichPool := enrich.New(storage, metaProvider, log, enrich.Options{
+		Workers:       cfg.EnrichWorkers,
+		QueueSize:     cfg.EnrichQueueSize,
+		MaxAttempts:   cfg.EnrichMaxAttempts,
+		RetryDelay:    cfg.EnrichRetryDelay,
+		Timeout:       cfg.EnrichTimeout,
+		SweepInterval: cfg.EnrichSweepInterval,
+	})
+
+	if err := enrichPool.Start(workersCtx); err != nil {
+		panic(err)
+	}
+
+	refresher := refresh.New(storage, metaProvider, log, refresh.Options{
+		Interval:  cfg.RefreshInterval,
+		MaxAge:    cfg.RefreshMaxAge,
+		BatchSize: cfg.RefreshBatchSize,
+		Timeout:   cfg.RefreshTimeout,
+	})
+
+	refresher.Start(workersCtx)
+
+	linkValidator := validate.NewLinkValidator(validate.LinkOptions{
+		Timeout:      cfg.LinkCheckTimeout,
+		MaxRedirects: cfg.LinkCheckMaxRedirects,
+		MaxBodySize:  cfg.LinkCheckMaxBodySize,
+		AllowPrivate: cfg.LinkCheckAllowPrivate,
+	})
+
+	linkChecker := linkcheck.New(storage, linkValidator, log, linkcheck.Options{
+		Interval:    cfg.LinkCheckInterval,
+		MaxAge:      cfg.LinkCheckMaxAge,
+		BatchSize:   cfg.LinkCheckBatchSize,
+		Concurrency: cfg.LinkCheckConcurrency,
+	})
+
+	linkChecker.Start(workersCtx)
+
+	authenticator, err := newAuthenticator(cfg, log, storage)
+	if err != nil {
+		panic(err)
+	}
 
-	handler := handlers.New(storage, log, yourApiClient)
+	handler := handlers.New(storage, log, metaProvider, yourApiClient, enrichPool, refresher, linkValidator)
 
 	gin.SetMode(gin.ReleaseMode)
 
@@ -52,14 +160,85 @@ func main() {
 	router.Use(logger.Middleware(log))
 	router.Use(gin.Recovery())
 
-	router.POST("/song", handler.SaveSong(30*time.Second))
-	router.GET("/library", handler.GetLibrary(30*time.Second))
-	router.GET("/song/:id/text", handler.GetSongText(30*time.Second))
-	router.DELETE("/song/:id", handler.DeleteSong(30*time.Second))
-	router.PATCH("/song/:id", handler.SongUpdate(30*time.Second))
-
 	router.GET("/swagger/:any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	api := router.Group("/", authenticator.Middleware())
+
+	reader := api.Group("/", auth.Require(auth.RoleReader))
+	reader.GET("/library", handler.GetLibrary(30*time.Second))
+	reader.GET("/song/:id/text", handler.GetSongText(30*time.Second))
+	reader.GET("/song/:id/links", handler.GetSongLinks(30*time.Second))
+	reader.GET("/song/:id/changes", handler.GetSongChanges(30*time.Second))
+	reader.GET("/jobs/:id", handler.GetJob(30*time.Second))
+	reader.GET("/reports/broken-links", handler.GetBrokenLinks(30*time.Second))
+	reader.GET("/playlists/:id", handler.GetPlaylist(30*time.Second))
+	reader.GET("/albums", handler.GetAlbums(30*time.Second))
+	reader.GET("/albums/:id", handler.GetAlbum(30*time.Second))
+	reader.GET("/artists", handler.SearchArtists(30*time.Second))
+	reader.GET("/artists/:id", handler.GetArtist(30*time.Second))
+	reader.GET("/groups/:id/members", handler.GetGroupMembers(30*time.Second))
+	reader.GET("/song/:id/credits", handler.GetSongCredits(30*time.Second))
+	reader.GET("/tags", handler.GetTags(30*time.Second))
+	reader.GET("/song/:id/tags", handler.GetSongTags(30*time.Second))
+	reader.GET("/groups/:id/tags", handler.GetGroupTags(30*time.Second))
+	reader.GET("/attributes", handler.GetAttributeDefinitions(30*time.Second))
+	reader.GET("/song/:id/attributes", handler.GetSongAttributes(30*time.Second))
+	reader.GET("/groups/:id/attributes", handler.GetGroupAttributes(30*time.Second))
+	reader.GET("/song/:id/related", handler.GetRelatedSongs(30*time.Second))
+
+	editor := api.Group("/", auth.Require(auth.RoleEditor))
+	editor.POST("/song",
+		idempotency.Middleware(storage, log, cfg.IdempotencyWindow, cfg.IdempotencyLease),
+		handler.SaveSong(30*time.Second),
+	)
+	editor.DELETE("/song/:id", handler.DeleteSong(30*time.Second))
+	editor.PATCH("/song/:id", handler.SongUpdate(30*time.Second))
+	editor.POST("/song/:id/links", handler.CreateSongLink(30*time.Second))
+	editor.PATCH("/song/:id/links/:link_id", handler.UpdateSongLink(30*time.Second))
+	editor.DELETE("/song/:id/links/:link_id", handler.DeleteSongLink(30*time.Second))
+	editor.POST("/song/:id/refresh", handler.RefreshSong(30*time.Second))
+	editor.POST("/albums", handler.CreateAlbum(30*time.Second))
+	editor.PATCH("/albums/:id", handler.UpdateAlbum(30*time.Second))
+	editor.DELETE("/albums/:id", handler.DeleteAlbum(30*time.Second))
+	editor.POST("/artists", handler.CreateArtist(30*time.Second))
+	editor.PATCH("/artists/:id", handler.UpdateArtist(30*time.Second))
+	editor.DELETE("/artists/:id", handler.DeleteArtist(30*time.Second))
+	editor.PUT("/groups/:id/members/:artist_id", handler.SetGroupMember(30*time.Second))
+	editor.DELETE("/groups/:id/members/:artist_id", handler.RemoveGroupMember(30*time.Second))
+	editor.POST("/song/:id/credits", handler.AddSongCredit(30*time.Second))
+	editor.DELETE("/song/:id/credits/:artist_id/:role", handler.RemoveSongCredit(30*time.Second))
+	editor.PUT("/song/:id/tags", handler.SetSongTags(30*time.Second))
+	editor.PUT("/groups/:id/tags", handler.SetGroupTags(30*time.Second))
+	editor.PATCH("/song/:id/attributes", handler.UpdateSongAttributes(30*time.Second))
+	editor.PATCH("/groups/:id/attributes", handler.UpdateGroupAttributes(30*time.Second))
+	editor.POST("/song/:id/relations", handler.AddSongRelation(30*time.Second))
+	editor.DELETE("/song/:id/relations/:related_id/:type", handler.RemoveSongRelation(30*time.Second))
+
+	user := api.Group("/", auth.Require(auth.RoleReader), auth.Authenticated())
+	user.GET("/me", handler.GetMe(30*time.Second))
+	user.GET("/me/favorites", handler.GetFavorites(30*time.Second))
+	user.PUT("/song/:id/favorite", handler.AddFavorite(30*time.Second))
+	user.DELETE("/song/:id/favorite", handler.RemoveFavorite(30*time.Second))
+	user.PUT("/song/:id/rating", handler.RateSong(30*time.Second))
+	user.DELETE("/song/:id/rating", handler.DeleteRating(30*time.Second))
+	user.GET("/me/playlists", handler.GetUserPlaylists(30*time.Second))
+	user.POST("/playlists", handler.CreatePlaylist(30*time.Second))
+	user.PATCH("/playlists/:id", handler.UpdatePlaylist(30*time.Second))
+	user.DELETE("/playlists/:id", handler.DeletePlaylist(30*time.Second))
+	user.POST("/playlists/:id/duplicate", handler.DuplicatePlaylist(30*time.Second))
+	user.POST("/playlists/:id/songs", handler.AddPlaylistSong(30*time.Second))
+	user.DELETE("/playlists/:id/songs/:song_id", handler.RemovePlaylistSong(30*time.Second))
+	user.PUT("/playlists/:id/order", handler.ReorderPlaylist(30*time.Second))
+
+	admin := api.Group("/admin", auth.Require(auth.RoleAdmin))
+	admin.GET("/cache", handler.GetCacheStats())
+	admin.DELETE("/cache", handler.FlushCache())
+	admin.POST("/keys", handler.IssueAPIKey(30*time.Second))
+	admin.GET("/keys", handler.ListAPIKeys(30*time.Second))
+	admin.DELETE("/keys/:id", handler.RevokeAPIKey(30*time.Second))
+	admin.POST("/attributes", handler.CreateAttributeDefinition(30*time.Second))
+	admin.DELETE("/attributes/:name", handler.DeleteAttributeDefinition(30*time.Second))
+
 	log.Info("server starting", slog.String("address", cfg.Addr))
 
 	srv := &http.Server{
@@ -88,5 +267,72 @@ func main() {
 		log.Info("failed to shutdown server", slog.String("error", err.Error()))
 	}
 
+	stopWorkers()
+	enrichPool.Wait()
+	refresher.Wait()
+	linkChecker.Wait()
+
 	log.Info("server shutdown", slog.String("address", srv.Addr))
 }
+
+// newMetadataProvider builds the chain of metadata providers in the order set by METADATA_PROVIDERS.
+func newMetadataProvider(cfg *config.Config, log *slog.Logger, yourApiClient *your_api.Client) (metadata.MetadataProvider, error) {
+	const fn = "main.newMetadataProvider"
+
+	var providers []metadata.NamedProvider
+
+	for _, name := range cfg.MetadataProviders {
+		switch name {
+		case "your_api":
+			providers = append(providers, metadata.NamedProvider{Name: name, Provider: yourApiClient})
+		case "dir":
+			dir, err := metadata.NewDir(cfg.MetadataDir)
+			if err != nil {
+				return nil, e.Wrap(fn, err)
+			}
+
+			log.Info("local song info loaded", slog.String("dir", cfg.MetadataDir), slog.Int("songs", dir.Len()))
+
+			providers = append(providers, metadata.NamedProvider{Name: name, Provider: dir})
+		default:
+			return nil, e.Wrap(fn, fmt.Errorf("unknown metadata provider %q", name))
+		}
+	}
+
+	if len(providers) == 0 {
+		return nil, e.Wrap(fn, errors.New("no metadata providers configured"))
+	}
+
+	return metadata.NewChain(log, providers...), nil
+}
+
+// newAuthenticator sets up api key and JWT authentication, HS256 tokens are accepted with AUTH_JWT_SECRET
+// and RS256 tokens with AUTH_JWT_PUBLIC_KEY_FILE.
+func newAuthenticator(cfg *config.Config, log *slog.Logger, store auth.Store) (*auth.Authenticator, error) {
+	const fn = "main.newAuthenticator"
+
+	if cfg.AuthAnonymousRole != "" && !auth.ValidRole(cfg.AuthAnonymousRole) {
+		return nil, e.Wrap(fn, fmt.Errorf("unknown anonymous role %q", cfg.AuthAnonymousRole))
+	}
+
+	jwtOpts := jwt.Options{
+		HMACSecret: []byte(cfg.AuthJWTSecret),
+		Issuer:     cfg.AuthJWTIssuer,
+		Audience:   cfg.AuthJWTAudience,
+		Leeway:     cfg.AuthJWTLeeway,
+	}
+
+	if cfg.AuthJWTPublicKeyFile != "" {
+		key, err := jwt.LoadRSAPublicKey(cfg.AuthJWTPublicKeyFile)
+		if err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		jwtOpts.RSAPublicKey = key
+	}
+
+	return auth.New(store, jwt.NewVerifier(jwtOpts), log, auth.Options{
+		AnonymousRole: cfg.AuthAnonymousRole,
+		BootstrapKey:  cfg.AuthBootstrapKey,
+	}), nil
+}
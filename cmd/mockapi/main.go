@@ -0,0 +1,211 @@
+// Command mockapi serves the song info API contract (GET /info?group=&song=) from a fixtures file,
+// so the music library can be run and tested without the real YOUR_API_URL.
+//
+//	go run ./cmd/mockapi -addr localhost:1234 -fixtures cmd/mockapi/fixtures.json -latency 200ms -error-rate 0.1
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"test_task/internal/lib/l"
+	"test_task/internal/lib/l/sl"
+	"test_task/pkg/e"
+	"time"
+)
+
+type fixture struct {
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+type songDetail struct {
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+type options struct {
+	latency         time.Duration
+	jitter          time.Duration
+	errorRate       float64
+	badRequestRate  float64
+	unavailableRate float64
+	retryAfter      int
+}
+
+func main() {
+	var (
+		addr     = flag.String("addr", "localhost:1234", "address to listen on")
+		fixtures = flag.String("fixtures", "cmd/mockapi/fixtures.json", "path to the fixtures file")
+		env      = flag.String("slog", "local", "logger type: local, dev or prod")
+		opts     options
+	)
+
+	flag.DurationVar(&opts.latency, "latency", 0, "latency added to every response")
+	flag.DurationVar(&opts.jitter, "jitter", 0, "random extra latency up to this value")
+	flag.Float64Var(&opts.errorRate, "error-rate", 0, "share of requests answered with 500, from 0 to 1")
+	flag.Float64Var(&opts.badRequestRate, "bad-request-rate", 0, "share of requests answered with 400, from 0 to 1")
+	flag.Float64Var(&opts.unavailableRate, "unavailable-rate", 0, "share of requests answered with 503, from 0 to 1")
+	flag.IntVar(&opts.retryAfter, "retry-after", 0, "Retry-After seconds sent with 503 responses, 0 omits the header")
+	flag.Parse()
+
+	log := l.SetupLogger(*env)
+
+	songs, err := loadFixtures(*fixtures)
+	if err != nil {
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /info", infoHandler(log, songs, opts))
+
+	srv := &http.Server{
+		Addr:        *addr,
+		Handler:     mux,
+		IdleTimeout: 60 * time.Second,
+	}
+
+	log.Info("mock api starting",
+		slog.String("address", *addr),
+		slog.Int("songs", len(songs)),
+	)
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			panic(e.Wrap("failed to start server", err))
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	<-stop
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Info("failed to shutdown server", sl.Err(err))
+	}
+
+	log.Info("mock api shutdown")
+}
+
+func infoHandler(log *slog.Logger, songs map[string]songDetail, opts options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		group := r.URL.Query().Get("group")
+		song := r.URL.Query().Get("song")
+
+		log := log.With(
+			slog.String("group", group),
+			slog.String("song", song),
+		)
+
+		delay := opts.latency
+		if opts.jitter > 0 {
+			delay += rand.N(opts.jitter)
+		}
+
+		if delay > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+
+		switch roll := rand.Float64(); {
+		case roll < opts.errorRate:
+			log.Debug("injected error")
+
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		case roll < opts.errorRate+opts.unavailableRate:
+			log.Debug("injected unavailable")
+
+			if opts.retryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(opts.retryAfter))
+			}
+
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		case roll < opts.errorRate+opts.unavailableRate+opts.badRequestRate:
+			log.Debug("injected bad request")
+
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		if group == "" || song == "" {
+			log.Debug("group or song is empty")
+
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		detail, ok := songs[key(group, song)]
+		if !ok {
+			log.Debug("song not found")
+
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		log.Debug("song info sent")
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(detail); err != nil {
+			log.Error("failed to write response", sl.Err(err))
+		}
+	}
+}
+
+func loadFixtures(path string) (map[string]songDetail, error) {
+	const fn = "main.loadFixtures"
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	var fixtures []fixture
+
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	songs := make(map[string]songDetail, len(fixtures))
+
+	for _, f := range fixtures {
+		songs[key(f.Group, f.Song)] = songDetail{
+			ReleaseDate: f.ReleaseDate,
+			Text:        f.Text,
+			Link:        f.Link,
+		}
+	}
+
+	return songs, nil
+}
+
+func key(group, song string) string {
+	return strings.ToLower(strings.TrimSpace(group)) + "\x00" + strings.ToLower(strings.TrimSpace(song))
+}
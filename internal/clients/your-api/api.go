@@ -3,73 +3,326 @@ package your_api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
+	"strconv"
 	"test_task/pkg/e"
+	"time"
 )
 
+type Options struct {
+	// APIKey is sent in the APIKeyHeader header (X-API-Key by default), BearerToken in the Authorization header.
+	APIKey       string
+	APIKeyHeader string
+	BearerToken  string
+
+	// CAFile is a PEM bundle trusted in addition to the system roots,
+	// CertFile and KeyFile are the PEM client certificate and key for mutual TLS.
+	CAFile   string
+	CertFile string
+	KeyFile  string
+
+	// Timeout limits a single attempt, the whole call is limited by the caller's context.
+	Timeout      time.Duration
+	MaxIdleConns int
+
+	// MaxRetries is the number of retries after the first attempt on 5xx and network errors.
+	MaxRetries  int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+
+	// BreakerThreshold is the number of consecutive failures that opens the circuit breaker, 0 disables it.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	// RateLimit is the max number of requests per second with bursts up to RateBurst, 0 disables the limit.
+	// MaxInFlight caps concurrent requests, 0 means no cap.
+	RateLimit   float64
+	RateBurst   int
+	MaxInFlight int
+
+	// CacheSize is the max number of cached lookups, 0 disables the cache.
+	// 400 responses are cached for CacheNegativeTTL, 0 disables negative caching.
+	CacheSize        int
+	CacheTTL         time.Duration
+	CacheNegativeTTL time.Duration
+
+	// Mapping describes the shape of the response, nil means DefaultMapping.
+	Mapping *Mapping
+
+	// RecordMode record saves every exchange with your api to RecordDir,
+	// replay serves the saved exchanges instead of going to the network.
+	RecordMode RecordMode
+	RecordDir  string
+}
+
 type Client struct {
-	host   string
-	client http.Client
+	baseURL *url.URL
+	client  http.Client
+	opts    Options
+	mapping Mapping
+	breaker *breaker
+	limiter *limiter
+	cache   *cache
 }
 
-func NewClient(host string) *Client {
-	return &Client{
-		host:   host,
-		client: http.Client{},
+// NewClient creates a client for your api at baseURL, e.g. https://example.com/api/v5,
+// song info is requested from the info path under it.
+func NewClient(baseURL string, opts Options) (*Client, error) {
+	const fn = "your_api.NewClient"
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, e.Wrap(fn, fmt.Errorf("invalid url %q: scheme must be http or https", baseURL))
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if opts.MaxIdleConns > 0 {
+		transport.MaxIdleConns = opts.MaxIdleConns
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConns
+	}
+
+	tlsConfig, err := newTLSConfig(opts)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	mapping := DefaultMapping()
+
+	if opts.Mapping != nil {
+		if err := opts.Mapping.Validate(); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		mapping = *opts.Mapping
+	}
+
+	if opts.APIKeyHeader == "" {
+		opts.APIKeyHeader = "X-API-Key"
+	}
+
+	var roundTripper http.RoundTripper = transport
+
+	switch opts.RecordMode {
+	case RecordModeRecord:
+		roundTripper = NewRecorder(opts.RecordDir, transport)
+	case RecordModeReplay:
+		roundTripper = NewReplayer(opts.RecordDir)
 	}
+
+	return &Client{
+		baseURL: u,
+		client:  http.Client{Transport: roundTripper},
+		opts:    opts,
+		mapping: mapping,
+		breaker: newBreaker(opts.BreakerThreshold, opts.BreakerCooldown),
+		limiter: newLimiter(opts.RateLimit, opts.RateBurst, opts.MaxInFlight),
+		cache:   newCache(opts.CacheSize, opts.CacheTTL, opts.CacheNegativeTTL),
+	}, nil
 }
 
 func (c *Client) GetSongInfo(ctx context.Context, group, song string) (*Response, error) {
 	const fn = "your_api.GetSongInfo"
 
+	key := cacheKey(group, song)
+
+	if ent, ok := c.cache.get(ctx, key); ok {
+		if ent.err != nil {
+			return nil, e.Wrap(fn, ent.err)
+		}
+
+		res := ent.resp
+
+		return &res, nil
+	}
+
+	res, err := c.fetch(ctx, group, song)
+
+	c.cache.set(key, res, err)
+
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return res, nil
+}
+
+func (c *Client) fetch(ctx context.Context, group, song string) (*Response, error) {
 	q := url.Values{}
 	q.Add("group", group)
 	q.Add("song", song)
 
-	u := url.URL{
-		Scheme: "http",
-		Host:   c.host,
-		Path:   "info",
+	u := c.baseURL.JoinPath("info")
+	u.RawQuery = q.Encode()
+
+	for attempt := 1; ; attempt++ {
+		release, err := c.limiter.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if !c.breaker.allow() {
+			release()
+
+			return nil, ErrCircuitOpen
+		}
+
+		res, err := c.do(ctx, u.String())
+
+		release()
+
+		// the caller gave up, the error says nothing about your api
+		if err != nil && ctx.Err() != nil {
+			c.breaker.abort()
+
+			return nil, ctx.Err()
+		}
+
+		c.breaker.record(!isUpstreamFailure(err))
+
+		if err == nil {
+			return res, nil
+		}
+
+		if !retryable(err) || attempt > c.opts.MaxRetries {
+			return nil, &RequestError{Attempts: attempt, Err: err}
+		}
+
+		delay := c.backoff(attempt)
+
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+			delay = statusErr.RetryAfter
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return nil, &RequestError{Attempts: attempt, Err: err}
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
 	}
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
-	if err != nil {
-		return nil, e.Wrap(fn, err)
+func (c *Client) do(ctx context.Context, URL string) (*Response, error) {
+	if c.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.opts.Timeout)
+		defer cancel()
 	}
 
-	req.URL.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL, nil)
+	if err != nil {
+		return nil, err
+	}
 
-	req.Close = true
+	if c.opts.APIKey != "" {
+		req.Header.Set(c.opts.APIKeyHeader, c.opts.APIKey)
+	}
 
-	resp, err := c.client.Do(req)
-	if resp != nil {
-		defer resp.Body.Close()
+	if c.opts.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.opts.BearerToken)
 	}
 
+	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, e.Wrap(fn, err)
+		return nil, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusBadRequest {
-			return nil, e.Wrap(fn, ErrBadRequest)
-		} else {
-			return nil, e.Wrap(fn, ErrInternalServerError)
+		// drain the body so the connection can be reused
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
 		}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, e.Wrap(fn, err)
+		return nil, err
 	}
 
-	var res Response
+	return c.mapping.decode(body)
+}
 
-	if err := json.Unmarshal(body, &res); err != nil {
-		return nil, e.Wrap(fn, err)
+// backoff returns the full jitter exponential delay before the next attempt.
+func (c *Client) backoff(attempt int) time.Duration {
+	if c.opts.BackoffBase <= 0 {
+		return 0
+	}
+
+	delay := c.opts.BackoffBase << (attempt - 1)
+	if delay <= 0 || (c.opts.BackoffMax > 0 && delay > c.opts.BackoffMax) {
+		delay = c.opts.BackoffMax
+	}
+
+	return rand.N(delay + 1)
+}
+
+// retryable reports whether the request may succeed if repeated: upstream failures and 429.
+func retryable(err error) bool {
+	return isUpstreamFailure(err) || errors.Is(err, ErrTooManyRequests)
+}
+
+// isUpstreamFailure reports whether the error means your api is unhealthy: 5xx statuses and network errors.
+func isUpstreamFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	if errors.Is(err, ErrNotRecorded) || errors.Is(err, ErrInvalidResponse) {
+		return false
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	return !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr)
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
 	}
 
-	return &res, nil
+	return 0
 }
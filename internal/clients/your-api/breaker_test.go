@@ -0,0 +1,92 @@
+package your_api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerTransitions(t *testing.T) {
+	b := newBreaker(2, 20*time.Millisecond)
+
+	b.record(false)
+
+	if !b.allow() || b.state != breakerClosed {
+		t.Fatal("breaker opened before the threshold")
+	}
+
+	// a success resets the consecutive failures
+	b.record(true)
+	b.record(false)
+
+	if b.state != breakerClosed {
+		t.Fatal("breaker counted failures that were not consecutive")
+	}
+
+	b.record(false)
+
+	if b.state != breakerOpen || b.allow() {
+		t.Fatal("breaker did not open at the threshold")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	// after the cooldown a single probe is let through
+	if !b.allow() || b.state != breakerHalfOpen {
+		t.Fatal("breaker did not let the probe through after the cooldown")
+	}
+
+	if b.allow() {
+		t.Fatal("breaker let a second request through while half-open")
+	}
+
+	// a failed probe reopens the breaker for another cooldown
+	b.record(false)
+
+	if b.state != breakerOpen || b.allow() {
+		t.Fatal("failed probe did not reopen the breaker")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if !b.allow() {
+		t.Fatal("breaker did not let the probe through after the second cooldown")
+	}
+
+	// a successful probe closes the breaker
+	b.record(true)
+
+	if b.state != breakerClosed || !b.allow() || !b.allow() {
+		t.Fatal("successful probe did not close the breaker")
+	}
+}
+
+func TestBreakerAbortedProbe(t *testing.T) {
+	b := newBreaker(1, 20*time.Millisecond)
+
+	b.record(false)
+
+	time.Sleep(30 * time.Millisecond)
+
+	if !b.allow() {
+		t.Fatal("breaker did not let the probe through after the cooldown")
+	}
+
+	// the caller canceled the probe, the next request probes instead
+	b.abort()
+
+	if !b.allow() || b.state != breakerHalfOpen {
+		t.Fatal("aborted probe did not let the next request probe")
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := newBreaker(0, time.Minute)
+
+	for i := 0; i < 10; i++ {
+		b.record(false)
+	}
+
+	if !b.allow() {
+		t.Error("disabled breaker rejected a request")
+	}
+}
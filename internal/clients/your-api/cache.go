@@ -0,0 +1,120 @@
+package your_api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"test_task/internal/metadata"
+	"test_task/pkg/lru"
+	"time"
+)
+
+type CacheStats struct {
+	Enabled      bool
+	Entries      int
+	Capacity     int
+	Hits         uint64
+	NegativeHits uint64
+	Misses       uint64
+}
+
+// cacheEntry holds either a response or, for negative caching, the 400 error returned by your api.
+type cacheEntry struct {
+	resp Response
+	err  error
+}
+
+type cache struct {
+	entries      *lru.Cache[string, cacheEntry]
+	ttl          time.Duration
+	negativeTTL  time.Duration
+	hits         atomic.Uint64
+	negativeHits atomic.Uint64
+	misses       atomic.Uint64
+}
+
+func newCache(size int, ttl, negativeTTL time.Duration) *cache {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+
+	return &cache{
+		entries:     lru.New[string, cacheEntry](size),
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+	}
+}
+
+func cacheKey(group, song string) string {
+	return group + "\x00" + song
+}
+
+func (c *cache) get(ctx context.Context, key string) (cacheEntry, bool) {
+	if c == nil {
+		return cacheEntry{}, false
+	}
+
+	// the fresh result of a metadata.WithoutCache lookup is still stored
+	if metadata.CacheDisabled(ctx) {
+		c.misses.Add(1)
+
+		return cacheEntry{}, false
+	}
+
+	ent, ok := c.entries.Get(key)
+	if !ok {
+		c.misses.Add(1)
+
+		return cacheEntry{}, false
+	}
+
+	if ent.err != nil {
+		c.negativeHits.Add(1)
+	} else {
+		c.hits.Add(1)
+	}
+
+	return ent, true
+}
+
+func (c *cache) set(key string, resp *Response, err error) {
+	if c == nil {
+		return
+	}
+
+	if err == nil {
+		c.entries.Set(key, cacheEntry{resp: *resp}, c.ttl)
+
+		return
+	}
+
+	var statusErr *StatusError
+	if c.negativeTTL > 0 && errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusBadRequest {
+		c.entries.Set(key, cacheEntry{err: statusErr}, c.negativeTTL)
+	}
+}
+
+func (c *Client) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+
+	return CacheStats{
+		Enabled:      true,
+		Entries:      c.cache.entries.Len(),
+		Capacity:     c.cache.entries.Capacity(),
+		Hits:         c.cache.hits.Load(),
+		NegativeHits: c.cache.negativeHits.Load(),
+		Misses:       c.cache.misses.Load(),
+	}
+}
+
+// FlushCache drops all cached entries and returns their number. The hit/miss counters are kept.
+func (c *Client) FlushCache() int {
+	if c.cache == nil {
+		return 0
+	}
+
+	return c.cache.entries.Purge()
+}
@@ -0,0 +1,174 @@
+package your_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+var ErrRateLimited = errors.New("outbound rate limit exceeded")
+
+// RateLimitError is returned when a request can not be sent before the caller's deadline
+// because of the local rate limit or concurrency cap, it unwraps to ErrRateLimited.
+// RetryAfter is 0 when the concurrency cap is hit, as it is unknown when a slot frees up.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter <= 0 {
+		return ErrRateLimited.Error()
+	}
+
+	return fmt.Sprintf("%s, retry after %s", ErrRateLimited, e.RetryAfter)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// RetryAfter returns how long the caller should wait before retrying, 0 if the error carries no hint.
+func RetryAfter(err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter
+	}
+
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) {
+		return rateErr.RetryAfter
+	}
+
+	return 0
+}
+
+// limiter is a token bucket combined with a cap on requests in flight.
+// Waiting is deadline aware: if the caller's deadline comes before a slot is available, it fails at once.
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	inFlight chan struct{}
+}
+
+func newLimiter(rate float64, burst, maxInFlight int) *limiter {
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+
+	l := &limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+
+	if maxInFlight > 0 {
+		l.inFlight = make(chan struct{}, maxInFlight)
+	}
+
+	return l
+}
+
+// acquire waits for a token and an in flight slot, the returned func releases the slot.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	if err := l.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	if l.inFlight == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l.inFlight <- struct{}{}:
+		return func() { <-l.inFlight }, nil
+	default:
+	}
+
+	select {
+	case l.inFlight <- struct{}{}:
+		return func() { <-l.inFlight }, nil
+	case <-ctx.Done():
+		l.unreserve()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, &RateLimitError{}
+		}
+
+		return nil, ctx.Err()
+	}
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	delay := l.reserve(ctx)
+	if delay < 0 {
+		return &RateLimitError{RetryAfter: -delay}
+	}
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.unreserve()
+
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token and returns how long to wait for it. If the wait does not fit
+// into the caller's deadline, no token is taken and the negated wait is returned.
+func (l *limiter) reserve(ctx context.Context) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+
+		return 0
+	}
+
+	delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		return -delay
+	}
+
+	l.tokens--
+
+	return delay
+}
+
+// unreserve gives back the token taken by reserve when the request is not sent.
+func (l *limiter) unreserve() {
+	if l.rate <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens = math.Min(l.burst, l.tokens+1)
+}
@@ -0,0 +1,130 @@
+package your_api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiterBurst(t *testing.T) {
+	l := newLimiter(1, 2, 0)
+
+	for i := 0; i < 2; i++ {
+		release, err := l.acquire(context.Background())
+		if err != nil {
+			t.Fatalf("acquire() #%d error = %v", i, err)
+		}
+
+		release()
+	}
+
+	if l.tokens >= 1 {
+		t.Errorf("tokens = %v, want the burst spent", l.tokens)
+	}
+}
+
+func TestLimiterRefill(t *testing.T) {
+	l := newLimiter(100, 1, 0)
+
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	release()
+
+	// the next token comes in 10ms
+	start := time.Now()
+
+	release, err = l.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	release()
+
+	if waited := time.Since(start); waited < 5*time.Millisecond {
+		t.Errorf("acquire() waited %s, want about 10ms", waited)
+	}
+}
+
+func TestLimiterDeadline(t *testing.T) {
+	l := newLimiter(1, 1, 0)
+
+	if _, err := l.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the next token comes in 1s, after the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	_, err := l.acquire(ctx)
+
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) || !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("acquire() error = %v, want %v", err, ErrRateLimited)
+	}
+
+	if rateErr.RetryAfter <= 0 || rateErr.RetryAfter > time.Second {
+		t.Errorf("RetryAfter = %s, want up to 1s", rateErr.RetryAfter)
+	}
+
+	if waited := time.Since(start); waited > 50*time.Millisecond {
+		t.Errorf("acquire() waited %s, want an immediate rejection", waited)
+	}
+}
+
+func TestLimiterCancelReturnsToken(t *testing.T) {
+	l := newLimiter(10, 1, 0)
+
+	if _, err := l.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the wait for the next token is canceled, the token goes back
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := l.acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("acquire() error = %v, want %v", err, context.Canceled)
+	}
+
+	if l.tokens < -0.5 {
+		t.Errorf("tokens = %v, want the canceled reservation given back", l.tokens)
+	}
+}
+
+func TestLimiterInFlight(t *testing.T) {
+	l := newLimiter(0, 0, 1)
+
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = l.acquire(ctx)
+
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("acquire() error = %v, want %v", err, ErrRateLimited)
+	}
+
+	if RetryAfter(err) != 0 {
+		t.Errorf("RetryAfter() = %s, want no hint", RetryAfter(err))
+	}
+
+	release()
+
+	release, err = l.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire() after release error = %v", err)
+	}
+
+	release()
+}
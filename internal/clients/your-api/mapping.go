@@ -0,0 +1,223 @@
+package your_api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"test_task/pkg/e"
+	"time"
+)
+
+var (
+	ErrInvalidResponse = errors.New("invalid response")
+	ErrMissingField    = errors.New("required field is missing")
+	ErrInvalidDate     = errors.New("release date does not match any layout")
+)
+
+// layoutUnix parses the release date from unix seconds instead of a time layout.
+const layoutUnix = "unix"
+
+// FieldMapping points at a value in the response body. Path is a dot separated list of
+// object keys and array indexes, e.g. "data.tracks.0.lyrics".
+type FieldMapping struct {
+	Path     string `json:"path"`
+	Required bool   `json:"required,omitempty"`
+
+	// Layouts are tried in order to parse the release date, "unix" reads unix seconds.
+	Layouts []string `json:"layouts,omitempty"`
+}
+
+// Mapping describes how a response of your api is turned into song info.
+// Album and TrackNumber are optional, they are not read with an empty path.
+type Mapping struct {
+	ReleaseDate FieldMapping `json:"release_date"`
+	Text        FieldMapping `json:"text"`
+	Link        FieldMapping `json:"link"`
+	Album       FieldMapping `json:"album,omitempty"`
+	TrackNumber FieldMapping `json:"track_number,omitempty"`
+}
+
+// MappingError is returned when a response does not satisfy the mapping, it unwraps to ErrInvalidResponse.
+type MappingError struct {
+	Field string
+	Path  string
+	Err   error
+}
+
+func (e *MappingError) Error() string {
+	return fmt.Sprintf("%s (%s): %s", e.Field, e.Path, e.Err)
+}
+
+func (e *MappingError) Unwrap() []error {
+	return []error{ErrInvalidResponse, e.Err}
+}
+
+// DefaultMapping matches the original contract: {"releaseDate": "DD.MM.YYYY", "text": "...", "link": "..."},
+// the album and trackNumber fields are read when present.
+func DefaultMapping() Mapping {
+	return Mapping{
+		ReleaseDate: FieldMapping{Path: "releaseDate", Layouts: []string{"02.01.2006"}},
+		Text:        FieldMapping{Path: "text"},
+		Link:        FieldMapping{Path: "link"},
+		Album:       FieldMapping{Path: "album"},
+		TrackNumber: FieldMapping{Path: "trackNumber"},
+	}
+}
+
+func LoadMapping(path string) (Mapping, error) {
+	const fn = "your_api.LoadMapping"
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Mapping{}, e.Wrap(fn, err)
+	}
+
+	var m Mapping
+
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Mapping{}, e.Wrap(fn, err)
+	}
+
+	if err := m.Validate(); err != nil {
+		return Mapping{}, e.Wrap(fn, err)
+	}
+
+	return m, nil
+}
+
+func (m Mapping) Validate() error {
+	fields := map[string]FieldMapping{
+		"release_date": m.ReleaseDate,
+		"text":         m.Text,
+		"link":         m.Link,
+	}
+
+	for name, field := range fields {
+		if field.Path == "" {
+			return fmt.Errorf("%s: path is empty", name)
+		}
+	}
+
+	if len(m.ReleaseDate.Layouts) == 0 {
+		return errors.New("release_date: no layouts")
+	}
+
+	return nil
+}
+
+// decode extracts song info from the body, the release date is returned in the DD.MM.YYYY format.
+func (m Mapping) decode(body []byte) (*Response, error) {
+	var doc any
+
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+
+	var res Response
+	var err error
+
+	if res.Text, err = m.Text.lookup("text", doc); err != nil {
+		return nil, err
+	}
+
+	if res.Link, err = m.Link.lookup("link", doc); err != nil {
+		return nil, err
+	}
+
+	if m.Album.Path != "" {
+		if res.Album, err = m.Album.lookup("album", doc); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.TrackNumber.Path != "" {
+		rawTrack, err := m.TrackNumber.lookup("track_number", doc)
+		if err != nil {
+			return nil, err
+		}
+
+		if n, err := strconv.Atoi(rawTrack); err == nil && n > 0 {
+			res.TrackNumber = n
+		}
+	}
+
+	rawDate, err := m.ReleaseDate.lookup("release_date", doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if rawDate != "" {
+		date, ok := parseDate(rawDate, m.ReleaseDate.Layouts)
+
+		switch {
+		case ok:
+			res.ReleaseDate = date.Format("02.01.2006")
+		case m.ReleaseDate.Required:
+			return nil, &MappingError{Field: "release_date", Path: m.ReleaseDate.Path, Err: ErrInvalidDate}
+		}
+	}
+
+	return &res, nil
+}
+
+func (f FieldMapping) lookup(field string, doc any) (string, error) {
+	value := doc
+
+	for _, key := range strings.Split(f.Path, ".") {
+		switch node := value.(type) {
+		case map[string]any:
+			value = node[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				value = nil
+			} else {
+				value = node[i]
+			}
+		default:
+			value = nil
+		}
+
+		if value == nil {
+			break
+		}
+	}
+
+	var s string
+
+	switch v := value.(type) {
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		s = strconv.FormatBool(v)
+	}
+
+	if s == "" && f.Required {
+		return "", &MappingError{Field: field, Path: f.Path, Err: ErrMissingField}
+	}
+
+	return s, nil
+}
+
+func parseDate(value string, layouts []string) (time.Time, bool) {
+	for _, layout := range layouts {
+		if layout == layoutUnix {
+			if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+				return time.Unix(seconds, 0).UTC(), true
+			}
+
+			continue
+		}
+
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, true
+		}
+	}
+
+	return time.Time{}, false
+}
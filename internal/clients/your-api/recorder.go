@@ -0,0 +1,150 @@
+package your_api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"test_task/pkg/e"
+)
+
+type RecordMode string
+
+const (
+	RecordModeOff    RecordMode = ""
+	RecordModeRecord RecordMode = "record"
+	RecordModeReplay RecordMode = "replay"
+)
+
+var ErrNotRecorded = errors.New("exchange is not recorded")
+
+func ParseRecordMode(mode string) (RecordMode, error) {
+	switch m := RecordMode(mode); m {
+	case RecordModeOff, RecordModeRecord, RecordModeReplay:
+		return m, nil
+	default:
+		return RecordModeOff, fmt.Errorf("unknown record mode %q", mode)
+	}
+}
+
+// exchange is a recorded request and response pair, stored as one JSON file per request.
+type exchange struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// Recorder is an http.RoundTripper that passes requests to next and saves every exchange to dir.
+type Recorder struct {
+	dir  string
+	next http.RoundTripper
+}
+
+func NewRecorder(dir string, next http.RoundTripper) *Recorder {
+	return &Recorder{
+		dir:  dir,
+		next: next,
+	}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	const fn = "your_api.Recorder.RoundTrip"
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	ex := exchange{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       string(body),
+	}
+
+	data, err := json.MarshalIndent(ex, "", "  ")
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if err := os.WriteFile(exchangePath(r.dir, req), data, 0o644); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return resp, nil
+}
+
+// Replayer is an http.RoundTripper that serves exchanges saved by Recorder and never touches the network.
+// Requests that were not recorded fail with ErrNotRecorded.
+type Replayer struct {
+	dir string
+}
+
+func NewReplayer(dir string) *Replayer {
+	return &Replayer{dir: dir}
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	const fn = "your_api.Replayer.RoundTrip"
+
+	data, err := os.ReadFile(exchangePath(r.dir, req))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, e.Wrap(fn, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, requestKey(req)))
+		}
+
+		return nil, e.Wrap(fn, err)
+	}
+
+	var ex exchange
+
+	if err := json.Unmarshal(data, &ex); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", ex.StatusCode, http.StatusText(ex.StatusCode)),
+		StatusCode:    ex.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        ex.Header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(ex.Body))),
+		ContentLength: int64(len(ex.Body)),
+		Request:       req,
+	}, nil
+}
+
+// requestKey identifies a request by path and query, the host is left out
+// so recordings can be replayed against any YOUR_API_URL.
+func requestKey(req *http.Request) string {
+	return req.URL.Path + "?" + req.URL.Query().Encode()
+}
+
+func exchangePath(dir string, req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + requestKey(req)))
+
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".json")
+}
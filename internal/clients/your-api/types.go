@@ -1,14 +1,56 @@
 package your_api
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"test_task/internal/metadata"
+	"time"
+)
 
-type Response struct {
-	ReleaseDate string `json:"releaseDate"`
-	Text        string `json:"text"`
-	Link        string `json:"link"`
-}
+// Response is the body of a successful /info response, Client implements metadata.MetadataProvider with it.
+type Response = metadata.SongInfo
 
 var (
 	ErrBadRequest          = errors.New("bad Request")
 	ErrInternalServerError = errors.New("internal Server Error")
+	ErrCircuitOpen         = errors.New("circuit breaker is open")
+	ErrTooManyRequests     = errors.New("too many requests")
 )
+
+// StatusError is returned when your api responds with a status other than 200.
+// It unwraps to ErrBadRequest and metadata.ErrNotFound for 400, to ErrTooManyRequests for 429
+// and to ErrInternalServerError otherwise.
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func (e *StatusError) Unwrap() []error {
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		return []error{ErrBadRequest, metadata.ErrNotFound}
+	case http.StatusTooManyRequests:
+		return []error{ErrTooManyRequests}
+	default:
+		return []error{ErrInternalServerError}
+	}
+}
+
+// RequestError is returned when the request failed after all attempts, Err is the error of the last attempt.
+type RequestError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("failed after %d attempt(s): %s", e.Attempts, e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"github.com/caarlos0/env/v6"
 	"github.com/joho/godotenv"
+	"time"
 )
 
 type Config struct {
@@ -14,6 +15,69 @@ type Config struct {
 	DBUser      string `env:"DB_USER"`
 	DBPassword  string `env:"DB_PASSWORD"`
 	YourAPIHost string `env:"YOUR_API_HOST"`
+
+	YourAPIURL            string `env:"YOUR_API_URL"`
+	YourAPIKey            string `env:"YOUR_API_KEY"`
+	YourAPIKeyHeader      string `env:"YOUR_API_KEY_HEADER" envDefault:"X-API-Key"`
+	YourAPIBearerToken    string `env:"YOUR_API_BEARER_TOKEN"`
+	YourAPICAFile         string `env:"YOUR_API_CA_FILE"`
+	YourAPIClientCertFile string `env:"YOUR_API_CLIENT_CERT_FILE"`
+	YourAPIClientKeyFile  string `env:"YOUR_API_CLIENT_KEY_FILE"`
+	YourAPIMappingFile    string `env:"YOUR_API_MAPPING_FILE"`
+
+	YourAPITimeout          time.Duration `env:"YOUR_API_TIMEOUT" envDefault:"10s"`
+	YourAPIMaxIdleConns     int           `env:"YOUR_API_MAX_IDLE_CONNS" envDefault:"10"`
+	YourAPIMaxRetries       int           `env:"YOUR_API_MAX_RETRIES" envDefault:"3"`
+	YourAPIBackoffBase      time.Duration `env:"YOUR_API_BACKOFF_BASE" envDefault:"200ms"`
+	YourAPIBackoffMax       time.Duration `env:"YOUR_API_BACKOFF_MAX" envDefault:"5s"`
+	YourAPIBreakerThreshold int           `env:"YOUR_API_BREAKER_THRESHOLD" envDefault:"5"`
+	YourAPIBreakerCooldown  time.Duration `env:"YOUR_API_BREAKER_COOLDOWN" envDefault:"30s"`
+
+	YourAPIRateLimit   float64 `env:"YOUR_API_RATE_LIMIT" envDefault:"0"`
+	YourAPIRateBurst   int     `env:"YOUR_API_RATE_BURST" envDefault:"0"`
+	YourAPIMaxInFlight int     `env:"YOUR_API_MAX_IN_FLIGHT" envDefault:"0"`
+
+	YourAPICacheSize        int           `env:"YOUR_API_CACHE_SIZE" envDefault:"1000"`
+	YourAPICacheTTL         time.Duration `env:"YOUR_API_CACHE_TTL" envDefault:"10m"`
+	YourAPICacheNegativeTTL time.Duration `env:"YOUR_API_CACHE_NEGATIVE_TTL" envDefault:"1m"`
+
+	YourAPIRecordMode string `env:"YOUR_API_RECORD_MODE"`
+	YourAPIRecordDir  string `env:"YOUR_API_RECORD_DIR" envDefault:"./testdata/your-api"`
+
+	MetadataProviders []string `env:"METADATA_PROVIDERS" envDefault:"your_api"`
+	MetadataDir       string   `env:"METADATA_DIR" envDefault:"./lyrics"`
+
+	LinkCheckTimeout      time.Duration `env:"LINK_CHECK_TIMEOUT" envDefault:"5s"`
+	LinkCheckMaxRedirects int           `env:"LINK_CHECK_MAX_REDIRECTS" envDefault:"5"`
+	LinkCheckMaxBodySize  int64         `env:"LINK_CHECK_MAX_BODY_SIZE" envDefault:"1048576"`
+	LinkCheckAllowPrivate bool          `env:"LINK_CHECK_ALLOW_PRIVATE" envDefault:"false"`
+	LinkCheckInterval     time.Duration `env:"LINK_CHECK_INTERVAL" envDefault:"1h"`
+	LinkCheckMaxAge       time.Duration `env:"LINK_CHECK_MAX_AGE" envDefault:"24h"`
+	LinkCheckBatchSize    int           `env:"LINK_CHECK_BATCH_SIZE" envDefault:"100"`
+	LinkCheckConcurrency  int           `env:"LINK_CHECK_CONCURRENCY" envDefault:"4"`
+
+	IdempotencyWindow time.Duration `env:"IDEMPOTENCY_WINDOW" envDefault:"24h"`
+	IdempotencyLease  time.Duration `env:"IDEMPOTENCY_LEASE" envDefault:"1m"`
+
+	AuthAnonymousRole    string        `env:"AUTH_ANONYMOUS_ROLE" envDefault:"reader"`
+	AuthBootstrapKey     string        `env:"AUTH_BOOTSTRAP_KEY"`
+	AuthJWTSecret        string        `env:"AUTH_JWT_SECRET"`
+	AuthJWTPublicKeyFile string        `env:"AUTH_JWT_PUBLIC_KEY_FILE"`
+	AuthJWTIssuer        string        `env:"AUTH_JWT_ISSUER"`
+	AuthJWTAudience      string        `env:"AUTH_JWT_AUDIENCE"`
+	AuthJWTLeeway        time.Duration `env:"AUTH_JWT_LEEWAY" envDefault:"30s"`
+
+	EnrichWorkers       int           `env:"ENRICH_WORKERS" envDefault:"4"`
+	EnrichQueueSize     int           `env:"ENRICH_QUEUE_SIZE" envDefault:"100"`
+	EnrichMaxAttempts   int           `env:"ENRICH_MAX_ATTEMPTS" envDefault:"5"`
+	EnrichRetryDelay    time.Duration `env:"ENRICH_RETRY_DELAY" envDefault:"2s"`
+	EnrichTimeout       time.Duration `env:"ENRICH_TIMEOUT" envDefault:"30s"`
+	EnrichSweepInterval time.Duration `env:"ENRICH_SWEEP_INTERVAL" envDefault:"1m"`
+
+	RefreshInterval  time.Duration `env:"REFRESH_INTERVAL" envDefault:"1h"`
+	RefreshMaxAge    time.Duration `env:"REFRESH_MAX_AGE" envDefault:"720h"`
+	RefreshBatchSize int           `env:"REFRESH_BATCH_SIZE" envDefault:"100"`
+	RefreshTimeout   time.Duration `env:"REFRESH_TIMEOUT" envDefault:"30s"`
 }
 
 func LoadEnvConfig(path string) (*Config, error) {
@@ -28,5 +92,10 @@ func LoadEnvConfig(path string) (*Config, error) {
 		return nil, err
 	}
 
+	// YOUR_API_HOST is kept for old config files, it never had a scheme
+	if cfg.YourAPIURL == "" && cfg.YourAPIHost != "" {
+		cfg.YourAPIURL = "http://" + cfg.YourAPIHost
+	}
+
 	return &cfg, nil
 }
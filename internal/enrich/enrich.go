@@ -0,0 +1,264 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/metadata"
+	"test_task/internal/storage"
+	"test_task/pkg/e"
+	"test_task/pkg/songlink"
+	"time"
+)
+
+var ErrQueueFull = errors.New("enrichment queue is full")
+
+type Options struct {
+	Workers       int
+	QueueSize     int
+	MaxAttempts   int
+	RetryDelay    time.Duration
+	Timeout       time.Duration
+	SweepInterval time.Duration
+}
+
+// Pool fills in song info from the metadata provider for songs saved in the pending state.
+// Jobs are persisted in storage, the in-memory queue only carries job ids,
+// so jobs that did not fit into the queue or were interrupted by a restart are picked up by the sweeper.
+type Pool struct {
+	db    storage.Storage
+	meta  metadata.MetadataProvider
+	log   *slog.Logger
+	opts  Options
+	queue chan int64
+	wg    sync.WaitGroup
+}
+
+func New(db storage.Storage, meta metadata.MetadataProvider, log *slog.Logger, opts Options) *Pool {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = opts.Workers
+	}
+
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+
+	return &Pool{
+		db:    db,
+		meta:  meta,
+		log:   log,
+		opts:  opts,
+		queue: make(chan int64, opts.QueueSize),
+	}
+}
+
+// Start launches the workers and the sweeper. They stop when ctx is canceled, use Wait to wait for them.
+func (p *Pool) Start(ctx context.Context) error {
+	const fn = "enrich.Start"
+
+	if err := p.db.ResetRunningJobs(ctx); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	for i := 0; i < p.opts.Workers; i++ {
+		p.wg.Add(1)
+
+		go func() {
+			defer p.wg.Done()
+
+			p.work(ctx)
+		}()
+	}
+
+	p.wg.Add(1)
+
+	go func() {
+		defer p.wg.Done()
+
+		p.sweep(ctx)
+	}()
+
+	return nil
+}
+
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
+
+// Enqueue schedules the job without blocking. The job stays pending in storage
+// if the queue is full and will be picked up by the sweeper later.
+func (p *Pool) Enqueue(jobID int64) error {
+	select {
+	case p.queue <- jobID:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (p *Pool) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case jobID := <-p.queue:
+			p.process(ctx, jobID)
+		}
+	}
+}
+
+func (p *Pool) sweep(ctx context.Context) {
+	if p.opts.SweepInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(p.opts.SweepInterval)
+	defer ticker.Stop()
+
+	p.enqueuePending(ctx, time.Now())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.enqueuePending(ctx, time.Now().Add(-p.opts.SweepInterval))
+		}
+	}
+}
+
+func (p *Pool) enqueuePending(ctx context.Context, updatedBefore time.Time) {
+	log := p.log.With(slog.String("fn", "enrich.enqueuePending"))
+
+	ids, err := p.db.PendingJobs(ctx, updatedBefore)
+	if err != nil {
+		log.Error("failed to get pending jobs", sl.Err(err))
+
+		return
+	}
+
+	for _, id := range ids {
+		if err := p.Enqueue(id); err != nil {
+			log.Warn("pending jobs left in storage", slog.Int64("jobID", id), sl.Err(err))
+
+			return
+		}
+	}
+}
+
+func (p *Pool) process(ctx context.Context, jobID int64) {
+	log := p.log.With(
+		slog.String("fn", "enrich.process"),
+		slog.Int64("jobID", jobID),
+	)
+
+	job, claimed, err := p.db.ClaimJob(ctx, jobID)
+	if err != nil {
+		log.Error("failed to claim job", sl.Err(err))
+
+		return
+	}
+
+	if !claimed {
+		log.Debug("job is not pending, skipped")
+
+		return
+	}
+
+	songID := int(job.SongID)
+
+	err = p.enrich(ctx, songID)
+	if err == nil {
+		if err := p.db.UpdateJob(ctx, jobID, storage.JobStatusDone, ""); err != nil {
+			log.Error("failed to update job", sl.Err(err))
+		}
+
+		log.Debug("song enriched", slog.Int("songID", songID))
+
+		return
+	}
+
+	if retryable(err) && job.Attempts < p.opts.MaxAttempts && ctx.Err() == nil {
+		log.Warn("enrichment failed, retrying", slog.Int("attempt", job.Attempts), sl.Err(err))
+
+		if err := p.db.UpdateJob(ctx, jobID, storage.JobStatusPending, err.Error()); err != nil {
+			log.Error("failed to update job", sl.Err(err))
+
+			return
+		}
+
+		delay := p.opts.RetryDelay << (job.Attempts - 1)
+
+		time.AfterFunc(delay, func() {
+			if err := p.Enqueue(jobID); err != nil {
+				log.Warn("job left for the sweeper", sl.Err(err))
+			}
+		})
+
+		return
+	}
+
+	log.Error("enrichment failed", slog.Int("attempt", job.Attempts), sl.Err(err))
+
+	if err := p.db.UpdateJob(ctx, jobID, storage.JobStatusFailed, err.Error()); err != nil {
+		log.Error("failed to update job", sl.Err(err))
+	}
+
+	if err := p.db.UpdateSong(ctx, songID, &storage.SongInfo{Status: storage.SongStatusFailed}); err != nil {
+		log.Error("failed to update song status", sl.Err(err))
+	}
+}
+
+func (p *Pool) enrich(ctx context.Context, songID int) error {
+	const fn = "enrich.enrich"
+
+	song, err := p.db.GetSong(ctx, songID)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, p.opts.Timeout)
+	defer cancel()
+
+	resp, err := p.meta.GetSongInfo(reqCtx, song.GroupName, song.Song)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	releaseDate, _ := time.Parse("02.01.2006", resp.ReleaseDate)
+	link := songlink.Normalize(resp.Link)
+
+	songInfo := &storage.SongInfo{
+		Date:       releaseDate,
+		Text:       resp.Text,
+		Link:       link.URL,
+		Platform:   link.Platform,
+		ExternalID: link.ExternalID,
+		Status:     storage.SongStatusReady,
+		Album:      resp.Album,
+	}
+
+	if resp.Album != "" && resp.TrackNumber > 0 {
+		songInfo.TrackNumber = &resp.TrackNumber
+	}
+
+	if err := p.db.UpdateSong(ctx, songID, songInfo); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	return nil
+}
+
+func retryable(err error) bool {
+	return !errors.Is(err, metadata.ErrNotFound) && !errors.Is(err, storage.ErrSongNotFound)
+}
@@ -0,0 +1,32 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"test_task/internal/metadata"
+	"test_task/internal/storage"
+	"test_task/pkg/e"
+	"testing"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"song info not found", metadata.ErrNotFound, false},
+		{"wrapped not found", e.Wrap("enrich.enrich", metadata.ErrNotFound), false},
+		{"song deleted", e.Wrap("enrich.enrich", storage.ErrSongNotFound), false},
+		{"timeout", e.Wrap("enrich.enrich", context.DeadlineExceeded), true},
+		{"upstream error", errors.New("upstream returned 502"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.err); got != tt.want {
+				t.Errorf("retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"test_task/internal/models"
+)
+
+// GetCacheStats godoc
+// @Summary Get your api cache stats
+// @Produce  json
+// @Success 200 {object} models.CacheStatsResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/cache [get]
+func (h *Handler) GetCacheStats() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetCacheStats"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		stats := h.yourApi.CacheStats()
+
+		var hitRatio float64
+
+		if lookups := stats.Hits + stats.NegativeHits + stats.Misses; lookups > 0 {
+			hitRatio = float64(stats.Hits+stats.NegativeHits) / float64(lookups)
+		}
+
+		log.Debug("cache stats sent", slog.Any("stats", stats))
+
+		c.JSON(http.StatusOK, models.CacheStatsResponse{
+			Enabled:      stats.Enabled,
+			Entries:      stats.Entries,
+			Capacity:     stats.Capacity,
+			Hits:         stats.Hits,
+			NegativeHits: stats.NegativeHits,
+			Misses:       stats.Misses,
+			HitRatio:     hitRatio,
+		})
+	}
+}
+
+// FlushCache godoc
+// @Summary Flush your api cache
+// @Produce  json
+// @Success 200 {object} models.FlushCacheResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/cache [delete]
+func (h *Handler) FlushCache() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.FlushCache"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		flushed := h.yourApi.FlushCache()
+
+		log.Info("cache flushed", slog.Int("entries", flushed))
+
+		c.JSON(http.StatusOK, models.FlushCacheResponse{
+			Message: "cache flushed",
+			Flushed: flushed,
+		})
+	}
+}
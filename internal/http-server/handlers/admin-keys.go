@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"test_task/internal/http-server/middleware/auth"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"time"
+)
+
+type IssueAPIKeyRequest struct {
+	Name string `json:"name" binding:"required"`
+	Role string `json:"role" binding:"required" example:"editor"`
+}
+
+// IssueAPIKey godoc
+// @Summary Issue an api key
+// @Description The key is returned only once, only its hash is stored. Roles: reader, editor, admin
+// @Accept  json
+// @Produce  json
+// @Param key body IssueAPIKeyRequest true "Key name and role"
+// @Success 201 {object} models.IssueAPIKeyResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 403 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/keys [post]
+func (h *Handler) IssueAPIKey(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.IssueAPIKey"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		var req IssueAPIKeyRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("failed to decode request", sl.Err(err))
+
+			c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+			return
+		}
+
+		if !auth.ValidRole(req.Role) {
+			log.Debug("role is invalid", slog.String("role", req.Role))
+
+			c.JSON(http.StatusBadRequest, ErrResp("role is invalid"))
+
+			return
+		}
+
+		key, prefix, hash, err := auth.GenerateKey()
+		if err != nil {
+			log.Error("failed to generate api key", sl.Err(err))
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		apiKey, err := h.db.CreateAPIKey(ctx, req.Name, req.Role, prefix, hash)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Info("api key issued",
+			slog.Int64("keyID", apiKey.KeyID),
+			slog.String("name", apiKey.Name),
+			slog.String("role", apiKey.Role))
+
+		c.JSON(http.StatusCreated, models.IssueAPIKeyResponse{
+			APIKey: *apiKey,
+			Key:    key,
+		})
+	}
+}
+
+// ListAPIKeys godoc
+// @Summary List api keys
+// @Produce  json
+// @Success 200 {object} models.APIKeysResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 403 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/keys [get]
+func (h *Handler) ListAPIKeys(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.ListAPIKeys"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		keys, err := h.db.ListAPIKeys(ctx)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("api keys sent", slog.Int("count", len(keys)))
+
+		c.JSON(http.StatusOK, models.APIKeysResponse{
+			Keys: keys,
+		})
+	}
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an api key
+// @Produce  json
+// @Param id path int true "Key ID"
+// @Success 200 {object} models.RevokeAPIKeyResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 403 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/keys/{id} [delete]
+func (h *Handler) RevokeAPIKey(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.RevokeAPIKey"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		if err := h.db.RevokeAPIKey(ctx, id); err != nil {
+			if errors.Is(err, storage.ErrAPIKeyNotFound) {
+				log.Debug(err.Error())
+
+				c.JSON(http.StatusNotFound, ErrResp("api key not found"))
+
+				return
+			}
+
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Info("api key revoked", slog.Int64("keyID", id))
+
+		c.JSON(http.StatusOK, models.RevokeAPIKeyResponse{
+			Message: "api key revoked",
+			KeyID:   id,
+		})
+	}
+}
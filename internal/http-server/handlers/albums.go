@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"time"
+)
+
+type CreateAlbumRequest struct {
+	GroupID     int64  `json:"group_id" binding:"required"`
+	Title       string `json:"title" binding:"required"`
+	ReleaseDate string `json:"release_date" example:"16.07.2006"`
+}
+
+type UpdateAlbumRequest struct {
+	Title *string `json:"title,omitempty"`
+	// ReleaseDate is cleared with an empty string.
+	ReleaseDate *string `json:"release_date,omitempty"`
+}
+
+// GetAlbums godoc
+// @Summary Get albums
+// @Produce  json
+// @Param group_id query int false "Albums of the group only"
+// @Success 200 {object} models.AlbumsResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /albums [get]
+func (h *Handler) GetAlbums(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetAlbums"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		var groupID int64
+
+		if groupIDStr := c.Query("group_id"); groupIDStr != "" {
+			var err error
+
+			groupID, err = strconv.ParseInt(groupIDStr, 10, 64)
+			if err != nil {
+				log.Debug("groupID is not a number")
+
+				c.JSON(http.StatusBadRequest, ErrResp("groupID is not a number"))
+
+				return
+			}
+		}
+
+		albums, err := h.db.GetAlbums(ctx, groupID)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("albums sent", slog.Int64("groupID", groupID), slog.Int("count", len(albums)))
+
+		c.JSON(http.StatusOK, models.AlbumsResponse{
+			Albums: albums,
+		})
+	}
+}
+
+// GetAlbum godoc
+// @Summary Get an album with its songs
+// @Description The songs are ordered by track number
+// @Produce  json
+// @Param id path int true "Album ID"
+// @Success 200 {object} models.Album
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /albums/{id} [get]
+func (h *Handler) GetAlbum(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetAlbum"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, ok := albumID(c, log)
+		if !ok {
+			return
+		}
+
+		album, err := h.db.GetAlbum(ctx, id)
+		if err != nil {
+			albumErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("album sent", slog.Int64("albumID", id))
+
+		c.JSON(http.StatusOK, album)
+	}
+}
+
+// CreateAlbum godoc
+// @Summary Create an album of a group
+// @Accept  json
+// @Produce  json
+// @Param album body CreateAlbumRequest true "Album"
+// @Success 201 {object} models.Album
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 409 {object} ErrResponse "The group already has an album with this title"
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /albums [post]
+func (h *Handler) CreateAlbum(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.CreateAlbum"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		var req CreateAlbumRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("failed to decode request", sl.Err(err))
+
+			c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+			return
+		}
+
+		req.Title = strings.TrimSpace(req.Title)
+
+		if req.Title == "" {
+			log.Debug("album title is empty")
+
+			c.JSON(http.StatusBadRequest, ErrResp("title is empty"))
+
+			return
+		}
+
+		var releaseDate time.Time
+
+		if req.ReleaseDate != "" {
+			var err error
+
+			releaseDate, err = time.Parse("02.01.2006", req.ReleaseDate)
+			if err != nil {
+				log.Debug(err.Error())
+
+				c.JSON(http.StatusBadRequest, ErrResp("release date is invalid, correct format: DD.MM.YYYY"))
+
+				return
+			}
+		}
+
+		album, err := h.db.CreateAlbum(ctx, req.GroupID, req.Title, releaseDate)
+		if err != nil {
+			albumErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("album created", slog.Int64("albumID", album.AlbumID), slog.Int64("groupID", album.GroupID))
+
+		c.JSON(http.StatusCreated, album)
+	}
+}
+
+// UpdateAlbum godoc
+// @Summary Update an album
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Album ID"
+// @Param album body UpdateAlbumRequest true "Update data"
+// @Success 200 {object} models.Album
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 409 {object} ErrResponse "The group already has an album with this title"
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /albums/{id} [patch]
+func (h *Handler) UpdateAlbum(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.UpdateAlbum"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, ok := albumID(c, log)
+		if !ok {
+			return
+		}
+
+		var req UpdateAlbumRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("failed to decode request", sl.Err(err))
+
+			c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+			return
+		}
+
+		update := &storage.AlbumUpdate{}
+
+		if req.Title != nil {
+			title := strings.TrimSpace(*req.Title)
+
+			if title == "" {
+				log.Debug("album title is empty")
+
+				c.JSON(http.StatusBadRequest, ErrResp("title is empty"))
+
+				return
+			}
+
+			update.Title = &title
+		}
+
+		if req.ReleaseDate != nil {
+			var releaseDate time.Time
+
+			if *req.ReleaseDate != "" {
+				var err error
+
+				releaseDate, err = time.Parse("02.01.2006", *req.ReleaseDate)
+				if err != nil {
+					log.Debug(err.Error())
+
+					c.JSON(http.StatusBadRequest, ErrResp("release date is invalid, correct format: DD.MM.YYYY"))
+
+					return
+				}
+			}
+
+			update.ReleaseDate = &releaseDate
+		}
+
+		album, err := h.db.UpdateAlbum(ctx, id, update)
+		if err != nil {
+			albumErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("album updated", slog.Int64("albumID", id))
+
+		c.JSON(http.StatusOK, album)
+	}
+}
+
+// DeleteAlbum godoc
+// @Summary Delete an album
+// @Description The songs of the album are kept without an album
+// @Produce  json
+// @Param id path int true "Album ID"
+// @Success 200 {object} models.DeleteAlbumResp
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /albums/{id} [delete]
+func (h *Handler) DeleteAlbum(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.DeleteAlbum"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, ok := albumID(c, log)
+		if !ok {
+			return
+		}
+
+		if err := h.db.DeleteAlbum(ctx, id); err != nil {
+			albumErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("album deleted", slog.Int64("albumID", id))
+
+		c.JSON(http.StatusOK, models.DeleteAlbumResp{
+			Message: "album deleted",
+			AlbumID: id,
+		})
+	}
+}
+
+func albumID(c *gin.Context, log *slog.Logger) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+		c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+		return 0, false
+	}
+
+	return id, true
+}
+
+func albumErr(c *gin.Context, log *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, storage.ErrAlbumNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("album not found"))
+
+	case errors.Is(err, storage.ErrGroupNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("group not found"))
+
+	case errors.Is(err, storage.ErrAlbumExists):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusConflict, ErrResp("the group already has an album with this title"))
+
+	case errors.Is(err, storage.ErrNoFieldsUpdate):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusBadRequest, ErrResp("no fields to update"))
+
+	default:
+		log.Error(err.Error())
+
+		c.Status(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"testing"
+	"time"
+)
+
+type albumStore struct {
+	storage.Storage
+
+	err     error
+	created *models.Album
+	date    time.Time
+}
+
+func (s *albumStore) CreateAlbum(_ context.Context, groupID int64, title string, releaseDate time.Time) (*models.Album, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	s.created = &models.Album{AlbumID: 1, GroupID: groupID, Title: title}
+	s.date = releaseDate
+
+	return s.created, nil
+}
+
+func TestCreateAlbum(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		body      string
+		err       error
+		status    int
+		wantTitle string
+		wantDate  time.Time
+	}{
+		{
+			name:      "created",
+			body:      `{"group_id":1,"title":"  Black Holes and Revelations ","release_date":"03.07.2006"}`,
+			status:    http.StatusCreated,
+			wantTitle: "Black Holes and Revelations",
+			wantDate:  time.Date(2006, time.July, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "without release date",
+			body:      `{"group_id":1,"title":"Origin of Symmetry"}`,
+			status:    http.StatusCreated,
+			wantTitle: "Origin of Symmetry",
+		},
+		{
+			name:   "blank title",
+			body:   `{"group_id":1,"title":"   "}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "without group",
+			body:   `{"title":"Absolution"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid release date",
+			body:   `{"group_id":1,"title":"Absolution","release_date":"2003-09-15"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unknown group",
+			body:   `{"group_id":2,"title":"Absolution"}`,
+			err:    storage.ErrGroupNotFound,
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "existing title",
+			body:   `{"group_id":1,"title":"Absolution"}`,
+			err:    storage.ErrAlbumExists,
+			status: http.StatusConflict,
+		},
+		{
+			name:   "storage error",
+			body:   `{"group_id":1,"title":"Absolution"}`,
+			err:    errors.New("connection refused"),
+			status: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &albumStore{err: tt.err}
+			h := &Handler{db: store, log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+			r := gin.New()
+			r.POST("/albums", h.CreateAlbum(time.Second))
+
+			req := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.status, w.Body)
+			}
+
+			if tt.status != http.StatusCreated {
+				return
+			}
+
+			if store.created.Title != tt.wantTitle || !store.date.Equal(tt.wantDate) {
+				t.Errorf("album created with %q, %s, want %q, %s", store.created.Title, store.date, tt.wantTitle, tt.wantDate)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"time"
+)
+
+type ArtistRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// SearchArtists godoc
+// @Summary Search artists by name
+// @Produce  json
+// @Param name query string false "Part of the name, case-insensitive"
+// @Param offset query int false " "
+// @Param limit query int false " "
+// @Success 200 {object} models.ArtistsResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /artists [get]
+func (h *Handler) SearchArtists(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.SearchArtists"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		var (
+			offset int
+			limit  int
+			err    error
+		)
+
+		if offsetStr := c.Query("offset"); offsetStr != "" {
+			offset, err = strconv.Atoi(offsetStr)
+			if err != nil || offset < 0 {
+				log.Debug("offset is invalid")
+
+				c.JSON(http.StatusBadRequest, ErrResp("offset is invalid"))
+
+				return
+			}
+		}
+
+		if limitStr := c.Query("limit"); limitStr != "" {
+			limit, err = strconv.Atoi(limitStr)
+			if err != nil || limit < 0 {
+				log.Debug("limit is invalid")
+
+				c.JSON(http.StatusBadRequest, ErrResp("limit is invalid"))
+
+				return
+			}
+		}
+
+		name := strings.TrimSpace(c.Query("name"))
+
+		artists, err := h.db.SearchArtists(ctx, name, offset, limit)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("artists sent", slog.String("name", name), slog.Int("count", len(artists)))
+
+		c.JSON(http.StatusOK, models.ArtistsResponse{
+			Artists: artists,
+		})
+	}
+}
+
+// GetArtist godoc
+// @Summary Get an artist with their groups and song credits
+// @Produce  json
+// @Param id path int true "Artist ID"
+// @Success 200 {object} models.Artist
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /artists/{id} [get]
+func (h *Handler) GetArtist(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetArtist"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, ok := int64Param(c, log, "id")
+		if !ok {
+			return
+		}
+
+		artist, err := h.db.GetArtist(ctx, id)
+		if err != nil {
+			artistErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("artist sent", slog.Int64("artistID", id))
+
+		c.JSON(http.StatusOK, artist)
+	}
+}
+
+// CreateArtist godoc
+// @Summary Create an artist
+// @Accept  json
+// @Produce  json
+// @Param artist body ArtistRequest true "Artist"
+// @Success 201 {object} models.Artist
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /artists [post]
+func (h *Handler) CreateArtist(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.CreateArtist"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		name, ok := artistName(c, log)
+		if !ok {
+			return
+		}
+
+		artist, err := h.db.CreateArtist(ctx, name)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("artist created", slog.Int64("artistID", artist.ArtistID))
+
+		c.JSON(http.StatusCreated, artist)
+	}
+}
+
+// UpdateArtist godoc
+// @Summary Rename an artist
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Artist ID"
+// @Param artist body ArtistRequest true "Artist"
+// @Success 200 {object} models.Artist
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /artists/{id} [patch]
+func (h *Handler) UpdateArtist(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.UpdateArtist"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, ok := int64Param(c, log, "id")
+		if !ok {
+			return
+		}
+
+		name, ok := artistName(c, log)
+		if !ok {
+			return
+		}
+
+		artist, err := h.db.UpdateArtist(ctx, id, name)
+		if err != nil {
+			artistErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("artist updated", slog.Int64("artistID", id))
+
+		c.JSON(http.StatusOK, artist)
+	}
+}
+
+// DeleteArtist godoc
+// @Summary Delete an artist
+// @Description Group memberships and song credits of the artist are deleted too
+// @Produce  json
+// @Param id path int true "Artist ID"
+// @Success 200 {object} models.DeleteArtistResp
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /artists/{id} [delete]
+func (h *Handler) DeleteArtist(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.DeleteArtist"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, ok := int64Param(c, log, "id")
+		if !ok {
+			return
+		}
+
+		if err := h.db.DeleteArtist(ctx, id); err != nil {
+			artistErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("artist deleted", slog.Int64("artistID", id))
+
+		c.JSON(http.StatusOK, models.DeleteArtistResp{
+			Message:  "artist deleted",
+			ArtistID: id,
+		})
+	}
+}
+
+func artistName(c *gin.Context, log *slog.Logger) (string, bool) {
+	var req ArtistRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Error("failed to decode request", sl.Err(err))
+
+		c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+		return "", false
+	}
+
+	name := strings.TrimSpace(req.Name)
+
+	if name == "" {
+		log.Debug("artist name is empty")
+
+		c.JSON(http.StatusBadRequest, ErrResp("name is empty"))
+
+		return "", false
+	}
+
+	return name, true
+}
+
+func int64Param(c *gin.Context, log *slog.Logger, name string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil {
+		log.Debug(name+" is invalid", slog.String(name, c.Param(name)))
+
+		c.JSON(http.StatusBadRequest, ErrResp(strings.ReplaceAll(name, "_", " ")+" is invalid"))
+
+		return 0, false
+	}
+
+	return id, true
+}
+
+func artistErr(c *gin.Context, log *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, storage.ErrArtistNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("artist not found"))
+
+	case errors.Is(err, storage.ErrGroupNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("group not found"))
+
+	case errors.Is(err, storage.ErrSongNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("song not found"))
+
+	case errors.Is(err, storage.ErrMemberNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("artist is not a member of the group"))
+
+	case errors.Is(err, storage.ErrCreditNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("credit not found"))
+
+	default:
+		log.Error(err.Error())
+
+		c.Status(http.StatusInternalServerError)
+	}
+}
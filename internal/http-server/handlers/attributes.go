@@ -0,0 +1,572 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"time"
+)
+
+// attributeFilterPrefix is the prefix of the library query parameters filtering by attributes.
+const attributeFilterPrefix = "attr."
+
+const maxAttributeNameLength = 64
+
+type CreateAttributeRequest struct {
+	// Name consists of lowercase latin letters, digits and underscores and starts with a letter.
+	Name        string `json:"name" binding:"required" example:"mood"`
+	Type        string `json:"type" binding:"required" example:"string"`
+	Description string `json:"description"`
+}
+
+// GetAttributeDefinitions godoc
+// @Summary Get declared custom attributes
+// @Produce  json
+// @Success 200 {object} models.AttributeDefinitionsResponse
+// @Failure 500
+// @Router /attributes [get]
+func (h *Handler) GetAttributeDefinitions(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetAttributeDefinitions"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		definitions, err := h.db.GetAttributeDefinitions(ctx)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("attribute definitions sent", slog.Int("count", len(definitions)))
+
+		c.JSON(http.StatusOK, models.AttributeDefinitionsResponse{
+			Attributes: definitions,
+		})
+	}
+}
+
+// CreateAttributeDefinition godoc
+// @Summary Declare a custom attribute of songs and groups
+// @Description Types: string, number, boolean
+// @Accept  json
+// @Produce  json
+// @Param attribute body CreateAttributeRequest true "Attribute"
+// @Success 201 {object} models.AttributeDefinition
+// @Failure 400 {object} ErrResponse
+// @Failure 409 {object} ErrResponse "The attribute is already declared"
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/attributes [post]
+func (h *Handler) CreateAttributeDefinition(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.CreateAttributeDefinition"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		var req CreateAttributeRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("failed to decode request", sl.Err(err))
+
+			c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+			return
+		}
+
+		if !validAttributeName(req.Name) {
+			log.Debug("attribute name is invalid", slog.String("name", req.Name))
+
+			c.JSON(http.StatusBadRequest, ErrResp("name is invalid, use lowercase latin letters, digits and underscores"))
+
+			return
+		}
+
+		if !validAttributeType(req.Type) {
+			log.Debug("attribute type is invalid", slog.String("type", req.Type))
+
+			c.JSON(http.StatusBadRequest, ErrResp("type is invalid"))
+
+			return
+		}
+
+		definition, err := h.db.CreateAttributeDefinition(ctx, &models.AttributeDefinition{
+			Name:        req.Name,
+			Type:        req.Type,
+			Description: strings.TrimSpace(req.Description),
+		})
+		if err != nil {
+			attributeErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("attribute declared", slog.String("name", definition.Name), slog.String("type", definition.Type))
+
+		c.JSON(http.StatusCreated, definition)
+	}
+}
+
+// DeleteAttributeDefinition godoc
+// @Summary Delete a custom attribute
+// @Description The values of the attribute are removed from all songs and groups
+// @Produce  json
+// @Param name path string true "Attribute name"
+// @Success 200 {object} models.DeleteAttributeResp
+// @Success 404 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/attributes/{name} [delete]
+func (h *Handler) DeleteAttributeDefinition(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.DeleteAttributeDefinition"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		name := c.Param("name")
+
+		if err := h.db.DeleteAttributeDefinition(ctx, name); err != nil {
+			attributeErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("attribute deleted", slog.String("name", name))
+
+		c.JSON(http.StatusOK, models.DeleteAttributeResp{
+			Message: "attribute deleted",
+			Name:    name,
+		})
+	}
+}
+
+// GetSongAttributes godoc
+// @Summary Get custom attributes of the song
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Success 200 {object} models.SongAttributesResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /song/{id}/attributes [get]
+func (h *Handler) GetSongAttributes(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetSongAttributes"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		attributes, err := h.db.GetSongAttributes(ctx, id)
+		if err != nil {
+			attributeErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("song attributes sent", slog.Int("songID", id))
+
+		c.JSON(http.StatusOK, models.SongAttributesResponse{
+			SongID:     id,
+			Attributes: attributes,
+		})
+	}
+}
+
+// UpdateSongAttributes godoc
+// @Summary Set custom attributes of the song
+// @Description The attributes must be declared, the values must have the declared type. A null value removes the attribute
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Param attributes body object true "Attribute values by name"
+// @Success 200 {object} models.SongAttributesResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/{id}/attributes [patch]
+func (h *Handler) UpdateSongAttributes(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.UpdateSongAttributes"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		set, remove, ok := h.attributeChanges(ctx, c, log)
+		if !ok {
+			return
+		}
+
+		attributes, err := h.db.UpdateSongAttributes(ctx, id, set, remove)
+		if err != nil {
+			attributeErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("song attributes updated", slog.Int("songID", id), slog.Int("set", len(set)), slog.Int("removed", len(remove)))
+
+		c.JSON(http.StatusOK, models.SongAttributesResponse{
+			SongID:     id,
+			Attributes: attributes,
+		})
+	}
+}
+
+// GetGroupAttributes godoc
+// @Summary Get custom attributes of a group
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Success 200 {object} models.GroupAttributesResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /groups/{id}/attributes [get]
+func (h *Handler) GetGroupAttributes(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetGroupAttributes"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		groupID, ok := int64Param(c, log, "id")
+		if !ok {
+			return
+		}
+
+		attributes, err := h.db.GetGroupAttributes(ctx, groupID)
+		if err != nil {
+			attributeErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("group attributes sent", slog.Int64("groupID", groupID))
+
+		c.JSON(http.StatusOK, models.GroupAttributesResponse{
+			GroupID:    groupID,
+			Attributes: attributes,
+		})
+	}
+}
+
+// UpdateGroupAttributes godoc
+// @Summary Set custom attributes of a group
+// @Description The attributes must be declared, the values must have the declared type. A null value removes the attribute.
+// @Description The songs of the group without their own value of an attribute are filtered by the value of the group
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Param attributes body object true "Attribute values by name"
+// @Success 200 {object} models.GroupAttributesResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /groups/{id}/attributes [patch]
+func (h *Handler) UpdateGroupAttributes(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.UpdateGroupAttributes"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		groupID, ok := int64Param(c, log, "id")
+		if !ok {
+			return
+		}
+
+		set, remove, ok := h.attributeChanges(ctx, c, log)
+		if !ok {
+			return
+		}
+
+		attributes, err := h.db.UpdateGroupAttributes(ctx, groupID, set, remove)
+		if err != nil {
+			attributeErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("group attributes updated", slog.Int64("groupID", groupID), slog.Int("set", len(set)), slog.Int("removed", len(remove)))
+
+		c.JSON(http.StatusOK, models.GroupAttributesResponse{
+			GroupID:    groupID,
+			Attributes: attributes,
+		})
+	}
+}
+
+// attributeChanges decodes the attribute values of the request and checks them against the declared attributes,
+// the attributes with a null value are returned in remove.
+func (h *Handler) attributeChanges(ctx context.Context, c *gin.Context, log *slog.Logger) (map[string]interface{}, []string, bool) {
+	var req map[string]interface{}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Error("failed to decode request", sl.Err(err))
+
+		c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+		return nil, nil, false
+	}
+
+	if len(req) == 0 {
+		log.Debug("no attributes to update")
+
+		c.JSON(http.StatusBadRequest, ErrResp("no fields to update"))
+
+		return nil, nil, false
+	}
+
+	types, ok := h.attributeTypes(ctx, c, log)
+	if !ok {
+		return nil, nil, false
+	}
+
+	set := make(map[string]interface{})
+	var remove []string
+
+	for name, value := range req {
+		attributeType, declared := types[name]
+		if !declared {
+			log.Debug("attribute is not declared", slog.String("name", name))
+
+			c.JSON(http.StatusBadRequest, ErrResp("attribute "+name+" is not declared"))
+
+			return nil, nil, false
+		}
+
+		if value == nil {
+			remove = append(remove, name)
+
+			continue
+		}
+
+		if !validAttributeValue(attributeType, value) {
+			log.Debug("attribute value has a wrong type", slog.String("name", name), slog.String("type", attributeType))
+
+			c.JSON(http.StatusBadRequest, ErrResp("attribute "+name+" must be a "+attributeType))
+
+			return nil, nil, false
+		}
+
+		set[name] = value
+	}
+
+	sort.Strings(remove)
+
+	return set, remove, true
+}
+
+// attributeFilters parses the attr.<name> query parameters of the library into values of the declared types.
+func (h *Handler) attributeFilters(ctx context.Context, c *gin.Context, log *slog.Logger) (map[string]interface{}, bool) {
+	params := make(map[string]string)
+
+	for key, values := range c.Request.URL.Query() {
+		if strings.HasPrefix(key, attributeFilterPrefix) && len(values) > 0 {
+			params[strings.TrimPrefix(key, attributeFilterPrefix)] = values[0]
+		}
+	}
+
+	if len(params) == 0 {
+		return nil, true
+	}
+
+	types, ok := h.attributeTypes(ctx, c, log)
+	if !ok {
+		return nil, false
+	}
+
+	filters := make(map[string]interface{})
+
+	for name, param := range params {
+		attributeType, declared := types[name]
+		if !declared {
+			log.Debug("attribute is not declared", slog.String("name", name))
+
+			c.JSON(http.StatusBadRequest, ErrResp("attribute "+name+" is not declared"))
+
+			return nil, false
+		}
+
+		var (
+			value interface{} = param
+			err   error
+		)
+
+		switch attributeType {
+		case storage.AttributeTypeNumber:
+			value, err = strconv.ParseFloat(param, 64)
+		case storage.AttributeTypeBoolean:
+			value, err = strconv.ParseBool(param)
+		}
+
+		if err != nil {
+			log.Debug("attribute filter has a wrong type", slog.String("name", name), slog.String("type", attributeType))
+
+			c.JSON(http.StatusBadRequest, ErrResp("attribute "+name+" must be a "+attributeType))
+
+			return nil, false
+		}
+
+		filters[name] = value
+	}
+
+	return filters, true
+}
+
+// attributeTypes returns the types of the declared attributes by name.
+func (h *Handler) attributeTypes(ctx context.Context, c *gin.Context, log *slog.Logger) (map[string]string, bool) {
+	definitions, err := h.db.GetAttributeDefinitions(ctx)
+	if err != nil {
+		log.Error(err.Error())
+
+		c.Status(http.StatusInternalServerError)
+
+		return nil, false
+	}
+
+	types := make(map[string]string, len(definitions))
+
+	for _, definition := range definitions {
+		types[definition.Name] = definition.Type
+	}
+
+	return types, true
+}
+
+func validAttributeName(name string) bool {
+	if name == "" || len(name) > maxAttributeNameLength || name[0] < 'a' || name[0] > 'z' {
+		return false
+	}
+
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func validAttributeType(attributeType string) bool {
+	switch attributeType {
+	case storage.AttributeTypeString, storage.AttributeTypeNumber, storage.AttributeTypeBoolean:
+		return true
+	}
+
+	return false
+}
+
+// validAttributeValue reports whether the decoded json value has the attribute type.
+func validAttributeValue(attributeType string, value interface{}) bool {
+	switch value.(type) {
+	case string:
+		return attributeType == storage.AttributeTypeString
+	case float64:
+		return attributeType == storage.AttributeTypeNumber
+	case bool:
+		return attributeType == storage.AttributeTypeBoolean
+	}
+
+	return false
+}
+
+func attributeErr(c *gin.Context, log *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, storage.ErrAttributeNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("attribute not found"))
+
+	case errors.Is(err, storage.ErrAttributeExists):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusConflict, ErrResp("attribute is already declared"))
+
+	case errors.Is(err, storage.ErrSongNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("song not found"))
+
+	case errors.Is(err, storage.ErrGroupNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("group not found"))
+
+	default:
+		log.Error(err.Error())
+
+		c.Status(http.StatusInternalServerError)
+	}
+}
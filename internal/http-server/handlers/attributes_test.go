@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidAttributeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"mood", true},
+		{"record_label", true},
+		{"top_100", true},
+		{strings.Repeat("a", maxAttributeNameLength), true},
+		{strings.Repeat("a", maxAttributeNameLength+1), false},
+		{"", false},
+		{"Mood", false},
+		{"_mood", false},
+		{"1st", false},
+		{"record-label", false},
+		{"record label", false},
+		{"настроение", false},
+	}
+
+	for _, tt := range tests {
+		if got := validAttributeName(tt.name); got != tt.want {
+			t.Errorf("validAttributeName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidAttributeValue(t *testing.T) {
+	tests := []struct {
+		attributeType string
+		value         interface{}
+		want          bool
+	}{
+		{"string", "calm", true},
+		{"string", "", true},
+		{"number", float64(42), true},
+		{"boolean", false, true},
+		{"string", float64(42), false},
+		{"number", "42", false},
+		{"boolean", "true", false},
+		{"string", nil, false},
+		{"string", []interface{}{"calm"}, false},
+		{"string", map[string]interface{}{"mood": "calm"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := validAttributeValue(tt.attributeType, tt.value); got != tt.want {
+			t.Errorf("validAttributeValue(%q, %#v) = %v, want %v", tt.attributeType, tt.value, got, tt.want)
+		}
+	}
+}
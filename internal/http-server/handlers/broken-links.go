@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"time"
+)
+
+// GetBrokenLinks godoc
+// @Summary Get songs with broken links found by the link checker
+// @Produce  json
+// @Param status query string false "broken, unreachable or invalid, all of them by default"
+// @Param offset query int false " "
+// @Param limit query int false " "
+// @Success 200 {object} models.BrokenLinksResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /reports/broken-links [get]
+func (h *Handler) GetBrokenLinks(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetBrokenLinks"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		var (
+			offset int
+			limit  int
+			err    error
+		)
+
+		if offsetStr := c.Query("offset"); offsetStr != "" {
+			offset, err = strconv.Atoi(offsetStr)
+			if err != nil || offset < 0 {
+				log.Debug("offset is invalid")
+
+				c.JSON(http.StatusBadRequest, ErrResp("offset is invalid"))
+
+				return
+			}
+		}
+
+		if limitStr := c.Query("limit"); limitStr != "" {
+			limit, err = strconv.Atoi(limitStr)
+			if err != nil || limit < 0 {
+				log.Debug("limit is invalid")
+
+				c.JSON(http.StatusBadRequest, ErrResp("limit is invalid"))
+
+				return
+			}
+		}
+
+		statuses := storage.BrokenLinkStatuses
+
+		if status := c.Query("status"); status != "" {
+			if status == storage.LinkStatusOK || status == storage.LinkStatusUnknown || !validLinkStatus(status) {
+				log.Debug("status is invalid", slog.String("status", status))
+
+				c.JSON(http.StatusBadRequest, ErrResp("status is invalid"))
+
+				return
+			}
+
+			statuses = []string{status}
+		}
+
+		links, err := h.db.GetBrokenLinks(ctx, statuses, offset, limit)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("broken links sent", slog.Int("count", len(links)))
+
+		c.JSON(http.StatusOK, models.BrokenLinksResponse{
+			BrokenLinks: links,
+		})
+	}
+}
+
+func validLinkStatus(status string) bool {
+	switch status {
+	case storage.LinkStatusUnknown, storage.LinkStatusOK, storage.LinkStatusBroken,
+		storage.LinkStatusUnreachable, storage.LinkStatusInvalid:
+		return true
+	}
+
+	return false
+}
@@ -20,6 +20,8 @@ import (
 // @Success 404 {object} ErrResponse
 // @Failure 400 {object} ErrResponse
 // @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /song/{id} [delete]
 func (h *Handler) DeleteSong(ctxTimeout time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,398 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"test_task/internal/http-server/middleware/auth"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"time"
+)
+
+type RateSongRequest struct {
+	Rating int `json:"rating" binding:"required" example:"5"`
+}
+
+// GetMe godoc
+// @Summary Get the current user
+// @Description The user is created on the first request with its api key or token subject
+// @Produce  json
+// @Success 200 {object} models.MeResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /me [get]
+func (h *Handler) GetMe(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetMe"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		user, ok := h.currentUser(ctx, c, log)
+		if !ok {
+			return
+		}
+
+		principal, _ := auth.GetPrincipal(c)
+
+		c.JSON(http.StatusOK, models.MeResponse{
+			User: *user,
+			Role: principal.Role,
+		})
+	}
+}
+
+// GetFavorites godoc
+// @Summary Get favorite songs of the current user
+// @Produce  json
+// @Param offset query int false " "
+// @Param limit query int false " "
+// @Success 200 {object} models.FavoritesResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /me/favorites [get]
+func (h *Handler) GetFavorites(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetFavorites"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		var (
+			offset int
+			limit  int
+			err    error
+		)
+
+		if offsetStr := c.Query("offset"); offsetStr != "" {
+			offset, err = strconv.Atoi(offsetStr)
+			if err != nil || offset < 0 {
+				log.Debug("offset is invalid")
+
+				c.JSON(http.StatusBadRequest, ErrResp("offset is invalid"))
+
+				return
+			}
+		}
+
+		if limitStr := c.Query("limit"); limitStr != "" {
+			limit, err = strconv.Atoi(limitStr)
+			if err != nil || limit < 0 {
+				log.Debug("limit is invalid")
+
+				c.JSON(http.StatusBadRequest, ErrResp("limit is invalid"))
+
+				return
+			}
+		}
+
+		user, ok := h.currentUser(ctx, c, log)
+		if !ok {
+			return
+		}
+
+		favorites, err := h.db.GetFavorites(ctx, user.UserID, offset, limit)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("favorites sent", slog.Int64("userID", user.UserID), slog.Int("count", len(favorites)))
+
+		c.JSON(http.StatusOK, models.FavoritesResponse{
+			Favorites: favorites,
+		})
+	}
+}
+
+// AddFavorite godoc
+// @Summary Add the song to favorites
+// @Description Adding a song twice is not an error
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Success 200 {object} models.FavoriteResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/{id}/favorite [put]
+func (h *Handler) AddFavorite(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.AddFavorite"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		user, ok := h.currentUser(ctx, c, log)
+		if !ok {
+			return
+		}
+
+		if err := h.db.AddFavorite(ctx, user.UserID, id); err != nil {
+			songErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("song added to favorites", slog.Int64("userID", user.UserID), slog.Int("songID", id))
+
+		c.JSON(http.StatusOK, models.FavoriteResponse{
+			SongID:    id,
+			Favorited: true,
+		})
+	}
+}
+
+// RemoveFavorite godoc
+// @Summary Remove the song from favorites
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Success 200 {object} models.FavoriteResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/{id}/favorite [delete]
+func (h *Handler) RemoveFavorite(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.RemoveFavorite"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		user, ok := h.currentUser(ctx, c, log)
+		if !ok {
+			return
+		}
+
+		if err := h.db.RemoveFavorite(ctx, user.UserID, id); err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("song removed from favorites", slog.Int64("userID", user.UserID), slog.Int("songID", id))
+
+		c.JSON(http.StatusOK, models.FavoriteResponse{
+			SongID:    id,
+			Favorited: false,
+		})
+	}
+}
+
+// RateSong godoc
+// @Summary Rate the song from 1 to 5
+// @Description Rating the song again replaces the previous rating
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Param rating body RateSongRequest true "Rating"
+// @Success 200 {object} models.RatingResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/{id}/rating [put]
+func (h *Handler) RateSong(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.RateSong"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		var req RateSongRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("failed to decode request", sl.Err(err))
+
+			c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+			return
+		}
+
+		if req.Rating < 1 || req.Rating > 5 {
+			log.Debug("rating is out of range", slog.Int("rating", req.Rating))
+
+			c.JSON(http.StatusBadRequest, ErrResp("rating must be from 1 to 5"))
+
+			return
+		}
+
+		user, ok := h.currentUser(ctx, c, log)
+		if !ok {
+			return
+		}
+
+		rating, err := h.db.RateSong(ctx, user.UserID, id, req.Rating)
+		if err != nil {
+			songErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("song rated", slog.Int64("userID", user.UserID), slog.Int("songID", id), slog.Int("rating", req.Rating))
+
+		c.JSON(http.StatusOK, rating)
+	}
+}
+
+// DeleteRating godoc
+// @Summary Delete the rating of the song
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Success 200 {object} models.RatingResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/{id}/rating [delete]
+func (h *Handler) DeleteRating(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.DeleteRating"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		user, ok := h.currentUser(ctx, c, log)
+		if !ok {
+			return
+		}
+
+		rating, err := h.db.DeleteRating(ctx, user.UserID, id)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("song rating deleted", slog.Int64("userID", user.UserID), slog.Int("songID", id))
+
+		c.JSON(http.StatusOK, rating)
+	}
+}
+
+// currentUser returns the user of the authenticated caller, anonymous callers get 401.
+func (h *Handler) currentUser(ctx context.Context, c *gin.Context, log *slog.Logger) (*models.User, bool) {
+	principal, ok := auth.GetPrincipal(c)
+	if !ok || principal.Method == auth.MethodAnonymous {
+		log.Debug("user is anonymous")
+
+		c.JSON(http.StatusUnauthorized, ErrResp("authentication required"))
+
+		return nil, false
+	}
+
+	user, err := h.db.EnsureUser(ctx, principal.Subject)
+	if err != nil {
+		log.Error(err.Error())
+
+		c.Status(http.StatusInternalServerError)
+
+		return nil, false
+	}
+
+	return user, true
+}
+
+func songErr(c *gin.Context, log *slog.Logger, err error) {
+	if errors.Is(err, storage.ErrSongNotFound) {
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("song not found"))
+
+		return
+	}
+
+	log.Error(err.Error())
+
+	c.Status(http.StatusInternalServerError)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"test_task/internal/storage"
+	"time"
+)
+
+// GetJob godoc
+// @Summary Get song enrichment job status
+// @Produce  json
+// @Param id path int true "Job ID"
+// @Success 200 {object} models.Job
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /jobs/{id} [get]
+func (h *Handler) GetJob(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetJob"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		idStr := c.Param("id")
+		if idStr == "" {
+			log.Debug("id is empty")
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is empty"))
+
+			return
+		}
+
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			log.Debug("id is invalid", slog.String("ID", idStr))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		job, err := h.db.GetJob(ctx, id)
+		if err != nil {
+			if errors.Is(err, storage.ErrJobNotFound) {
+				log.Debug(err.Error())
+
+				c.JSON(http.StatusNotFound, ErrResp("job not found"))
+
+				return
+			}
+
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("job sent", slog.Int64("jobID", id), slog.String("status", job.Status))
+
+		c.JSON(http.StatusOK, *job)
+	}
+}
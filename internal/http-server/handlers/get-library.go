@@ -6,14 +6,21 @@ import (
 	"github.com/gin-gonic/gin"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
+	"test_task/internal/http-server/middleware/auth"
 	"test_task/internal/models"
 	"test_task/internal/storage"
+	"test_task/pkg/songlink"
 	"time"
 )
 
+// viewAlbums is the library view with the songs nested into albums.
+const viewAlbums = "albums"
+
 // GetLibrary godoc
 // @Summary Get library
+// @Description Declared custom attributes are filtered with attr.<name>=<value>, e.g. attr.mood=happy
 // @Produce  json
 // @Param offset query int false " "
 // @Param limit query int false " "
@@ -24,6 +31,27 @@ import (
 // @Param release_date query string false " "
 // @Param song_text query string false " "
 // @Param link query string false " "
+// @Param link_status query string false "unknown, ok, broken, unreachable or invalid"
+// @Param platform query string false "youtube, soundcloud, bandcamp, spotify, apple_music, deezer or other"
+// @Param favorited query bool false "Only the favorites of the current user, requires authentication"
+// @Param sort query string false "rating orders the songs by the average rating"
+// @Param album_id query int false " "
+// @Param album query string false "Album title"
+// @Param artist query string false "Name of a group member or a credited artist, case-insensitive"
+// @Param artist_id query int false " "
+// @Param view query string false "albums nests the songs of every group into its albums"
+// @Param genre query []string false "Genres, repeated or comma-separated" collectionFormat(multi)
+// @Param tag query []string false "Tags, repeated or comma-separated" collectionFormat(multi)
+// @Param tag_match query string false "all (default) keeps the songs having every genre and tag, any keeps the songs having at least one"
+// @Param facets query bool false "Count the matching songs for every genre and tag"
+// @Param duration_min query int false "Seconds"
+// @Param duration_max query int false "Seconds"
+// @Param bpm_min query number false " "
+// @Param bpm_max query number false " "
+// @Param key query string false "Musical key like C, F#m or Bb"
+// @Param language query string false "Two-letter ISO 639-1 code"
+// @Param explicit query bool false " "
+// @Param covers query string false "include (default), exclude hides the covers, only keeps only them"
 // @Success 200 {object} models.GetLibraryResponse
 // @Success 404 {object} ErrResponse
 // @Failure 400 {object} ErrResponse
@@ -50,6 +78,27 @@ func (h *Handler) GetLibrary(ctxTimeout time.Duration) gin.HandlerFunc {
 		releaseDateStr := c.Query("release_date")
 		songText := c.Query("song_text")
 		link := c.Query("link")
+		linkStatus := c.Query("link_status")
+		platform := c.Query("platform")
+		favoritedStr := c.Query("favorited")
+		sortBy := c.Query("sort")
+		albumIDStr := c.Query("album_id")
+		albumTitle := c.Query("album")
+		view := c.Query("view")
+		artist := c.Query("artist")
+		artistIDStr := c.Query("artist_id")
+		genres := tagNames(c.QueryArray("genre"))
+		tags := tagNames(c.QueryArray("tag"))
+		tagMatch := c.Query("tag_match")
+		facetsStr := c.Query("facets")
+		durationMinStr := c.Query("duration_min")
+		durationMaxStr := c.Query("duration_max")
+		bpmMinStr := c.Query("bpm_min")
+		bpmMaxStr := c.Query("bpm_max")
+		key := c.Query("key")
+		language := c.Query("language")
+		explicitStr := c.Query("explicit")
+		covers := c.Query("covers")
 
 		var (
 			offset      int
@@ -57,6 +106,16 @@ func (h *Handler) GetLibrary(ctxTimeout time.Duration) gin.HandlerFunc {
 			groupID     int
 			songID      int
 			releaseDate time.Time
+			favorited   bool
+			userID      int64
+			albumID     int64
+			artistID    int64
+			withFacets  bool
+			durationMin int
+			durationMax int
+			bpmMin      float64
+			bpmMax      float64
+			explicit    *bool
 			err         error
 		)
 
@@ -115,6 +174,216 @@ func (h *Handler) GetLibrary(ctxTimeout time.Duration) gin.HandlerFunc {
 			}
 		}
 
+		if linkStatus != "" && !validLinkStatus(linkStatus) {
+			log.Debug("link status is invalid", slog.String("link_status", linkStatus))
+
+			c.JSON(http.StatusBadRequest, ErrResp("link status is invalid"))
+
+			return
+		}
+
+		if platform != "" && platform != songlink.PlatformOther && !slices.Contains(songlink.Platforms, platform) {
+			log.Debug("platform is unknown", slog.String("platform", platform))
+
+			c.JSON(http.StatusBadRequest, ErrResp("platform is unknown"))
+
+			return
+		}
+
+		if albumIDStr != "" {
+			albumID, err = strconv.ParseInt(albumIDStr, 10, 64)
+			if err != nil {
+				log.Debug("albumID is not a number")
+
+				c.JSON(http.StatusBadRequest, ErrResp("albumID is not a number"))
+
+				return
+			}
+		}
+
+		if artistIDStr != "" {
+			artistID, err = strconv.ParseInt(artistIDStr, 10, 64)
+			if err != nil {
+				log.Debug("artistID is not a number")
+
+				c.JSON(http.StatusBadRequest, ErrResp("artistID is not a number"))
+
+				return
+			}
+		}
+
+		if view != "" && view != viewAlbums {
+			log.Debug("view is invalid", slog.String("view", view))
+
+			c.JSON(http.StatusBadRequest, ErrResp("view is invalid"))
+
+			return
+		}
+
+		if tagMatch != "" && tagMatch != tagMatchAll && tagMatch != tagMatchAny {
+			log.Debug("tag match is invalid", slog.String("tag_match", tagMatch))
+
+			c.JSON(http.StatusBadRequest, ErrResp("tag_match is invalid"))
+
+			return
+		}
+
+		if facetsStr != "" {
+			withFacets, err = strconv.ParseBool(facetsStr)
+			if err != nil {
+				log.Debug("facets is not a boolean")
+
+				c.JSON(http.StatusBadRequest, ErrResp("facets is not a boolean"))
+
+				return
+			}
+		}
+
+		if durationMinStr != "" {
+			durationMin, err = strconv.Atoi(durationMinStr)
+			if err != nil || durationMin < 0 {
+				log.Debug("duration_min is invalid")
+
+				c.JSON(http.StatusBadRequest, ErrResp("duration_min is invalid"))
+
+				return
+			}
+		}
+
+		if durationMaxStr != "" {
+			durationMax, err = strconv.Atoi(durationMaxStr)
+			if err != nil || durationMax < 0 || (durationMax != 0 && durationMax < durationMin) {
+				log.Debug("duration_max is invalid")
+
+				c.JSON(http.StatusBadRequest, ErrResp("duration_max is invalid"))
+
+				return
+			}
+		}
+
+		if bpmMinStr != "" {
+			bpmMin, err = strconv.ParseFloat(bpmMinStr, 64)
+			if err != nil || bpmMin < 0 {
+				log.Debug("bpm_min is invalid")
+
+				c.JSON(http.StatusBadRequest, ErrResp("bpm_min is invalid"))
+
+				return
+			}
+		}
+
+		if bpmMaxStr != "" {
+			bpmMax, err = strconv.ParseFloat(bpmMaxStr, 64)
+			if err != nil || bpmMax < 0 || (bpmMax != 0 && bpmMax < bpmMin) {
+				log.Debug("bpm_max is invalid")
+
+				c.JSON(http.StatusBadRequest, ErrResp("bpm_max is invalid"))
+
+				return
+			}
+		}
+
+		if key != "" {
+			var ok bool
+
+			key, ok = musicalKey(key)
+			if !ok {
+				log.Debug("key is invalid")
+
+				c.JSON(http.StatusBadRequest, ErrResp("key is invalid"))
+
+				return
+			}
+		}
+
+		if language != "" {
+			var ok bool
+
+			language, ok = languageCode(language)
+			if !ok {
+				log.Debug("language is invalid")
+
+				c.JSON(http.StatusBadRequest, ErrResp("language is invalid"))
+
+				return
+			}
+		}
+
+		if explicitStr != "" {
+			value, err := strconv.ParseBool(explicitStr)
+			if err != nil {
+				log.Debug("explicit is not a boolean")
+
+				c.JSON(http.StatusBadRequest, ErrResp("explicit is not a boolean"))
+
+				return
+			}
+
+			explicit = &value
+		}
+
+		if covers != "" && covers != storage.CoversInclude && covers != storage.CoversExclude && covers != storage.CoversOnly {
+			log.Debug("covers is invalid", slog.String("covers", covers))
+
+			c.JSON(http.StatusBadRequest, ErrResp("covers is invalid"))
+
+			return
+		}
+
+		if sortBy != "" && sortBy != storage.SortByRating {
+			log.Debug("sort is invalid", slog.String("sort", sortBy))
+
+			c.JSON(http.StatusBadRequest, ErrResp("sort is invalid"))
+
+			return
+		}
+
+		if favoritedStr != "" {
+			favorited, err = strconv.ParseBool(favoritedStr)
+			if err != nil {
+				log.Debug("favorited is not a boolean")
+
+				c.JSON(http.StatusBadRequest, ErrResp("favorited is not a boolean"))
+
+				return
+			}
+		}
+
+		attributes, ok := h.attributeFilters(ctx, c, log)
+		if !ok {
+			return
+		}
+
+		// Favorites are marked for authenticated callers, filtering by them requires authentication.
+		// A caller without a user row has no favorites yet, the row is created on the first write.
+		if principal, ok := auth.GetPrincipal(c); ok && principal.Method != auth.MethodAnonymous {
+			user, err := h.db.GetUserBySubject(ctx, principal.Subject)
+			switch {
+			case err == nil:
+				userID = user.UserID
+			case errors.Is(err, storage.ErrUserNotFound):
+				if favorited {
+					log.Debug("caller has no favorites")
+
+					c.JSON(http.StatusNotFound, ErrResp("nothing found"))
+
+					return
+				}
+			default:
+				log.Error(err.Error())
+
+				c.Status(http.StatusInternalServerError)
+
+				return
+			}
+		} else if favorited {
+			log.Debug("favorited requires authentication")
+
+			c.JSON(http.StatusUnauthorized, ErrResp("authentication required"))
+
+			return
+		}
+
 		if limit < 0 {
 			limit = 0
 		}
@@ -133,9 +402,33 @@ func (h *Handler) GetLibrary(ctxTimeout time.Duration) gin.HandlerFunc {
 			ReleaseDate: releaseDate,
 			SongText:    songText,
 			Link:        link,
+			LinkStatus:  linkStatus,
+			Platform:    platform,
+			UserID:      userID,
+			Favorited:   favorited,
+			SortBy:      sortBy,
+			AlbumID:     albumID,
+			AlbumTitle:  albumTitle,
+			AlbumsView:  view == viewAlbums,
+			Artist:      artist,
+			ArtistID:    artistID,
+
+			Genres:       genres,
+			Tags:         tags,
+			TagsMatchAny: tagMatch == tagMatchAny,
+
+			DurationMin: durationMin,
+			DurationMax: durationMax,
+			BPMMin:      bpmMin,
+			BPMMax:      bpmMax,
+			Key:         key,
+			Language:    language,
+			Explicit:    explicit,
+			Attributes:  attributes,
+			Covers:      covers,
 		}
 
-		groupMap, err := h.db.GetLibrary(ctx, filters)
+		groups, err := h.db.GetLibrary(ctx, filters)
 		if err != nil {
 			if errors.Is(err, storage.ErrNothingFound) {
 				log.Debug(err.Error(), slog.Any("filters", filters))
@@ -151,10 +444,19 @@ func (h *Handler) GetLibrary(ctxTimeout time.Duration) gin.HandlerFunc {
 			return
 		}
 
-		var response models.GetLibraryResponse
+		response := models.GetLibraryResponse{
+			Library: groups,
+		}
+
+		if withFacets {
+			response.Facets, err = h.db.GetLibraryFacets(ctx, filters)
+			if err != nil {
+				log.Error(err.Error())
 
-		for _, group := range groupMap {
-			response.Library = append(response.Library, *group)
+				c.Status(http.StatusInternalServerError)
+
+				return
+			}
 		}
 
 		log.Debug("library data received successfully", slog.Any("filters", *filters))
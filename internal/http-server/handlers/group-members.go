@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/models"
+	"time"
+)
+
+type GroupMemberRequest struct {
+	JoinedYear int `json:"joined_year" example:"1994"`
+	// LeftYear is empty for current members.
+	LeftYear int `json:"left_year"`
+}
+
+// GetGroupMembers godoc
+// @Summary Get members of a group
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Success 200 {object} models.GroupMembersResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /groups/{id}/members [get]
+func (h *Handler) GetGroupMembers(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetGroupMembers"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		groupID, ok := int64Param(c, log, "id")
+		if !ok {
+			return
+		}
+
+		members, err := h.db.GetGroupMembers(ctx, groupID)
+		if err != nil {
+			artistErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("group members sent", slog.Int64("groupID", groupID), slog.Int("count", len(members)))
+
+		c.JSON(http.StatusOK, models.GroupMembersResponse{
+			GroupID: groupID,
+			Members: members,
+		})
+	}
+}
+
+// SetGroupMember godoc
+// @Summary Add an artist to a group or update the membership years
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Param artist_id path int true "Artist ID"
+// @Param member body GroupMemberRequest false "Membership years"
+// @Success 200 {object} models.GroupMembersResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /groups/{id}/members/{artist_id} [put]
+func (h *Handler) SetGroupMember(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.SetGroupMember"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		groupID, ok := int64Param(c, log, "id")
+		if !ok {
+			return
+		}
+
+		artistID, ok := int64Param(c, log, "artist_id")
+		if !ok {
+			return
+		}
+
+		var req GroupMemberRequest
+
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				log.Error("failed to decode request", sl.Err(err))
+
+				c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+				return
+			}
+		}
+
+		if !validYear(req.JoinedYear) || !validYear(req.LeftYear) ||
+			(req.JoinedYear != 0 && req.LeftYear != 0 && req.LeftYear < req.JoinedYear) {
+			log.Debug("membership years are invalid", slog.Int("joined_year", req.JoinedYear), slog.Int("left_year", req.LeftYear))
+
+			c.JSON(http.StatusBadRequest, ErrResp("membership years are invalid"))
+
+			return
+		}
+
+		if err := h.db.SetGroupMember(ctx, groupID, artistID, req.JoinedYear, req.LeftYear); err != nil {
+			artistErr(c, log, err)
+
+			return
+		}
+
+		members, err := h.db.GetGroupMembers(ctx, groupID)
+		if err != nil {
+			artistErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("group member set", slog.Int64("groupID", groupID), slog.Int64("artistID", artistID))
+
+		c.JSON(http.StatusOK, models.GroupMembersResponse{
+			GroupID: groupID,
+			Members: members,
+		})
+	}
+}
+
+// RemoveGroupMember godoc
+// @Summary Remove an artist from a group
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Param artist_id path int true "Artist ID"
+// @Success 200 {object} models.GroupMembersResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /groups/{id}/members/{artist_id} [delete]
+func (h *Handler) RemoveGroupMember(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.RemoveGroupMember"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		groupID, ok := int64Param(c, log, "id")
+		if !ok {
+			return
+		}
+
+		artistID, ok := int64Param(c, log, "artist_id")
+		if !ok {
+			return
+		}
+
+		if err := h.db.RemoveGroupMember(ctx, groupID, artistID); err != nil {
+			artistErr(c, log, err)
+
+			return
+		}
+
+		members, err := h.db.GetGroupMembers(ctx, groupID)
+		if err != nil {
+			artistErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("group member removed", slog.Int64("groupID", groupID), slog.Int64("artistID", artistID))
+
+		c.JSON(http.StatusOK, models.GroupMembersResponse{
+			GroupID: groupID,
+			Members: members,
+		})
+	}
+}
+
+// validYear reports whether year is unknown (0) or a four-digit year.
+func validYear(year int) bool {
+	return year == 0 || (year >= 1000 && year <= 9999)
+}
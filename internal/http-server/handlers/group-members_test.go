@@ -0,0 +1,25 @@
+package handlers
+
+import "testing"
+
+func TestValidYear(t *testing.T) {
+	tests := []struct {
+		year int
+		want bool
+	}{
+		{0, true},
+		{1000, true},
+		{1994, true},
+		{9999, true},
+		{-1994, false},
+		{94, false},
+		{999, false},
+		{10000, false},
+	}
+
+	for _, tt := range tests {
+		if got := validYear(tt.year); got != tt.want {
+			t.Errorf("validYear(%d) = %v, want %v", tt.year, got, tt.want)
+		}
+	}
+}
@@ -3,20 +3,35 @@ package handlers
 import (
 	"log/slog"
 	your_api "test_task/internal/clients/your-api"
+	"test_task/internal/enrich"
+	"test_task/internal/metadata"
+	"test_task/internal/refresh"
 	"test_task/internal/storage"
+	"test_task/pkg/singleflight"
+	"test_task/pkg/validate"
 )
 
 type Handler struct {
 	db      storage.Storage
 	log     *slog.Logger
+	meta    metadata.MetadataProvider
 	yourApi *your_api.Client
+	enrich  *enrich.Pool
+	refresh *refresh.Refresher
+	links   *validate.LinkValidator
+
+	saveFlight singleflight.Group[saveSongResult]
 }
 
-func New(db storage.Storage, log *slog.Logger, yourApi *your_api.Client) *Handler {
+func New(db storage.Storage, log *slog.Logger, meta metadata.MetadataProvider, yourApi *your_api.Client, enrich *enrich.Pool, refresh *refresh.Refresher, links *validate.LinkValidator) *Handler {
 	return &Handler{
 		db:      db,
 		log:     log,
+		meta:    meta,
 		yourApi: yourApi,
+		enrich:  enrich,
+		refresh: refresh,
+		links:   links,
 	}
 }
 
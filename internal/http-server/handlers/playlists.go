@@ -0,0 +1,636 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"time"
+)
+
+type CreatePlaylistRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type UpdatePlaylistRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+type AddPlaylistSongRequest struct {
+	SongID int `json:"song_id" binding:"required"`
+	// Position starts from 1, the song is appended without it.
+	Position int `json:"position"`
+}
+
+type ReorderPlaylistRequest struct {
+	SongIDs []int64 `json:"song_ids" binding:"required"`
+}
+
+type DuplicatePlaylistRequest struct {
+	Name string `json:"name"`
+}
+
+// CreatePlaylist godoc
+// @Summary Create a playlist of the current user
+// @Accept  json
+// @Produce  json
+// @Param playlist body CreatePlaylistRequest true "Playlist"
+// @Success 201 {object} models.Playlist
+// @Failure 400 {object} ErrResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlists [post]
+func (h *Handler) CreatePlaylist(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.CreatePlaylist"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		var req CreatePlaylistRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("failed to decode request", sl.Err(err))
+
+			c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+			return
+		}
+
+		req.Name = strings.TrimSpace(req.Name)
+
+		if req.Name == "" {
+			log.Debug("playlist name is empty")
+
+			c.JSON(http.StatusBadRequest, ErrResp("name is empty"))
+
+			return
+		}
+
+		user, ok := h.currentUser(ctx, c, log)
+		if !ok {
+			return
+		}
+
+		playlist, err := h.db.CreatePlaylist(ctx, user.UserID, req.Name, req.Description)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("playlist created", slog.Int64("playlistID", playlist.PlaylistID), slog.Int64("userID", user.UserID))
+
+		c.JSON(http.StatusCreated, playlist)
+	}
+}
+
+// GetUserPlaylists godoc
+// @Summary Get playlists of the current user
+// @Produce  json
+// @Success 200 {object} models.PlaylistsResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /me/playlists [get]
+func (h *Handler) GetUserPlaylists(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetUserPlaylists"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		user, ok := h.currentUser(ctx, c, log)
+		if !ok {
+			return
+		}
+
+		playlists, err := h.db.GetUserPlaylists(ctx, user.UserID)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("playlists sent", slog.Int64("userID", user.UserID), slog.Int("count", len(playlists)))
+
+		c.JSON(http.StatusOK, models.PlaylistsResponse{
+			Playlists: playlists,
+		})
+	}
+}
+
+// GetPlaylist godoc
+// @Summary Get a playlist with its songs
+// @Produce  json
+// @Param id path int true "Playlist ID"
+// @Success 200 {object} models.Playlist
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /playlists/{id} [get]
+func (h *Handler) GetPlaylist(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetPlaylist"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, ok := playlistID(c, log)
+		if !ok {
+			return
+		}
+
+		playlist, err := h.db.GetPlaylist(ctx, id)
+		if err != nil {
+			playlistErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("playlist sent", slog.Int64("playlistID", id))
+
+		c.JSON(http.StatusOK, playlist)
+	}
+}
+
+// UpdatePlaylist godoc
+// @Summary Rename a playlist or change its description
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Playlist ID"
+// @Param playlist body UpdatePlaylistRequest true "Update data"
+// @Success 200 {object} models.Playlist
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 403 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlists/{id} [patch]
+func (h *Handler) UpdatePlaylist(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.UpdatePlaylist"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, ok := playlistID(c, log)
+		if !ok {
+			return
+		}
+
+		var req UpdatePlaylistRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("failed to decode request", sl.Err(err))
+
+			c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+			return
+		}
+
+		if req.Name != nil {
+			name := strings.TrimSpace(*req.Name)
+
+			if name == "" {
+				log.Debug("playlist name is empty")
+
+				c.JSON(http.StatusBadRequest, ErrResp("name is empty"))
+
+				return
+			}
+
+			req.Name = &name
+		}
+
+		if !h.ownPlaylist(ctx, c, log, id) {
+			return
+		}
+
+		playlist, err := h.db.UpdatePlaylist(ctx, id, &storage.PlaylistUpdate{
+			Name:        req.Name,
+			Description: req.Description,
+		})
+		if err != nil {
+			playlistErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("playlist updated", slog.Int64("playlistID", id))
+
+		c.JSON(http.StatusOK, playlist)
+	}
+}
+
+// DeletePlaylist godoc
+// @Summary Delete a playlist
+// @Produce  json
+// @Param id path int true "Playlist ID"
+// @Success 200 {object} models.DeletePlaylistResp
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 403 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlists/{id} [delete]
+func (h *Handler) DeletePlaylist(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.DeletePlaylist"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, ok := playlistID(c, log)
+		if !ok {
+			return
+		}
+
+		if !h.ownPlaylist(ctx, c, log, id) {
+			return
+		}
+
+		if err := h.db.DeletePlaylist(ctx, id); err != nil {
+			playlistErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("playlist deleted", slog.Int64("playlistID", id))
+
+		c.JSON(http.StatusOK, models.DeletePlaylistResp{
+			Message:    "playlist deleted",
+			PlaylistID: id,
+		})
+	}
+}
+
+// DuplicatePlaylist godoc
+// @Summary Copy a playlist to the current user
+// @Description Any playlist can be copied, the copy is named "<name> (copy)" unless a name is given
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Playlist ID"
+// @Param playlist body DuplicatePlaylistRequest false "Name of the copy"
+// @Success 201 {object} models.Playlist
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlists/{id}/duplicate [post]
+func (h *Handler) DuplicatePlaylist(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.DuplicatePlaylist"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, ok := playlistID(c, log)
+		if !ok {
+			return
+		}
+
+		var req DuplicatePlaylistRequest
+
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				log.Error("failed to decode request", sl.Err(err))
+
+				c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+				return
+			}
+		}
+
+		user, ok := h.currentUser(ctx, c, log)
+		if !ok {
+			return
+		}
+
+		playlist, err := h.db.DuplicatePlaylist(ctx, id, user.UserID, strings.TrimSpace(req.Name))
+		if err != nil {
+			playlistErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("playlist duplicated", slog.Int64("playlistID", id), slog.Int64("copyID", playlist.PlaylistID))
+
+		c.JSON(http.StatusCreated, playlist)
+	}
+}
+
+// AddPlaylistSong godoc
+// @Summary Add a song to a playlist
+// @Description The song is inserted at the position moving the next songs down, without the position it is appended
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Playlist ID"
+// @Param song body AddPlaylistSongRequest true "Song"
+// @Success 200 {object} models.Playlist
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 403 {object} ErrResponse
+// @Failure 409 {object} ErrResponse "The song is already in the playlist"
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlists/{id}/songs [post]
+func (h *Handler) AddPlaylistSong(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.AddPlaylistSong"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, ok := playlistID(c, log)
+		if !ok {
+			return
+		}
+
+		var req AddPlaylistSongRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("failed to decode request", sl.Err(err))
+
+			c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+			return
+		}
+
+		if req.Position < 0 {
+			log.Debug("position is negative", slog.Int("position", req.Position))
+
+			c.JSON(http.StatusBadRequest, ErrResp("position is invalid"))
+
+			return
+		}
+
+		if !h.ownPlaylist(ctx, c, log, id) {
+			return
+		}
+
+		playlist, err := h.db.AddPlaylistSong(ctx, id, req.SongID, req.Position)
+		if err != nil {
+			playlistErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("song added to playlist", slog.Int64("playlistID", id), slog.Int("songID", req.SongID))
+
+		c.JSON(http.StatusOK, playlist)
+	}
+}
+
+// RemovePlaylistSong godoc
+// @Summary Remove a song from a playlist
+// @Produce  json
+// @Param id path int true "Playlist ID"
+// @Param song_id path int true "Song ID"
+// @Success 200 {object} models.Playlist
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 403 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlists/{id}/songs/{song_id} [delete]
+func (h *Handler) RemovePlaylistSong(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.RemovePlaylistSong"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, ok := playlistID(c, log)
+		if !ok {
+			return
+		}
+
+		songID, err := strconv.Atoi(c.Param("song_id"))
+		if err != nil {
+			log.Debug("song id is invalid", slog.String("song_id", c.Param("song_id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("song id is invalid"))
+
+			return
+		}
+
+		if !h.ownPlaylist(ctx, c, log, id) {
+			return
+		}
+
+		playlist, err := h.db.RemovePlaylistSong(ctx, id, songID)
+		if err != nil {
+			playlistErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("song removed from playlist", slog.Int64("playlistID", id), slog.Int("songID", songID))
+
+		c.JSON(http.StatusOK, playlist)
+	}
+}
+
+// ReorderPlaylist godoc
+// @Summary Reorder the songs of a playlist
+// @Description song_ids must list every song of the playlist once in the new order
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Playlist ID"
+// @Param order body ReorderPlaylistRequest true "New order"
+// @Success 200 {object} models.Playlist
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 401 {object} ErrResponse
+// @Failure 403 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlists/{id}/order [put]
+func (h *Handler) ReorderPlaylist(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.ReorderPlaylist"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, ok := playlistID(c, log)
+		if !ok {
+			return
+		}
+
+		var req ReorderPlaylistRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("failed to decode request", sl.Err(err))
+
+			c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+			return
+		}
+
+		if !h.ownPlaylist(ctx, c, log, id) {
+			return
+		}
+
+		playlist, err := h.db.ReorderPlaylist(ctx, id, req.SongIDs)
+		if err != nil {
+			playlistErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("playlist reordered", slog.Int64("playlistID", id))
+
+		c.JSON(http.StatusOK, playlist)
+	}
+}
+
+// ownPlaylist checks that the playlist belongs to the current user,
+// 404 is returned for unknown playlists and 403 for playlists of other users.
+func (h *Handler) ownPlaylist(ctx context.Context, c *gin.Context, log *slog.Logger, playlistID int64) bool {
+	user, ok := h.currentUser(ctx, c, log)
+	if !ok {
+		return false
+	}
+
+	playlist, err := h.db.GetPlaylist(ctx, playlistID)
+	if err != nil {
+		playlistErr(c, log, err)
+
+		return false
+	}
+
+	if playlist.OwnerID != user.UserID {
+		log.Debug("playlist belongs to another user", slog.Int64("playlistID", playlistID), slog.Int64("userID", user.UserID))
+
+		c.JSON(http.StatusForbidden, ErrResp("playlist belongs to another user"))
+
+		return false
+	}
+
+	return true
+}
+
+func playlistID(c *gin.Context, log *slog.Logger) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+		c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+		return 0, false
+	}
+
+	return id, true
+}
+
+func playlistErr(c *gin.Context, log *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, storage.ErrPlaylistNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("playlist not found"))
+
+	case errors.Is(err, storage.ErrSongNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("song not found"))
+
+	case errors.Is(err, storage.ErrPlaylistSongNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("song is not in the playlist"))
+
+	case errors.Is(err, storage.ErrPlaylistSongExists):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusConflict, ErrResp("song is already in the playlist"))
+
+	case errors.Is(err, storage.ErrInvalidOrder):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusBadRequest, ErrResp("song_ids must list every song of the playlist once"))
+
+	case errors.Is(err, storage.ErrNoFieldsUpdate):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusBadRequest, ErrResp("no fields to update"))
+
+	default:
+		log.Error(err.Error())
+
+		c.Status(http.StatusInternalServerError)
+	}
+}
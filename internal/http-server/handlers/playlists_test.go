@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test_task/internal/http-server/middleware/auth"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"testing"
+	"time"
+)
+
+// playlistStore keeps one playlist of the user with id 1, the calls that change it are counted.
+type playlistStore struct {
+	storage.Storage
+
+	playlist *models.Playlist
+	changes  int
+}
+
+func (s *playlistStore) AuthenticateAPIKey(_ context.Context, keyHash string) (*models.APIKey, error) {
+	switch keyHash {
+	case auth.HashKey("tt_owner"):
+		return &models.APIKey{KeyID: 1, Role: auth.RoleReader}, nil
+	case auth.HashKey("tt_other"):
+		return &models.APIKey{KeyID: 2, Role: auth.RoleReader}, nil
+	}
+
+	return nil, storage.ErrAPIKeyNotFound
+}
+
+func (s *playlistStore) EnsureUser(_ context.Context, subject string) (*models.User, error) {
+	ids := map[string]int64{"api_key:1": 1, "api_key:2": 2}
+
+	return &models.User{UserID: ids[subject], Subject: subject}, nil
+}
+
+func (s *playlistStore) GetPlaylist(_ context.Context, playlistID int64) (*models.Playlist, error) {
+	if playlistID != s.playlist.PlaylistID {
+		return nil, storage.ErrPlaylistNotFound
+	}
+
+	return s.playlist, nil
+}
+
+func (s *playlistStore) UpdatePlaylist(_ context.Context, _ int64, _ *storage.PlaylistUpdate) (*models.Playlist, error) {
+	s.changes++
+
+	return s.playlist, nil
+}
+
+func (s *playlistStore) DeletePlaylist(_ context.Context, _ int64) error {
+	s.changes++
+
+	return nil
+}
+
+func (s *playlistStore) AddPlaylistSong(_ context.Context, _ int64, _ int, _ int) (*models.Playlist, error) {
+	s.changes++
+
+	return s.playlist, nil
+}
+
+func (s *playlistStore) RemovePlaylistSong(_ context.Context, _ int64, _ int) (*models.Playlist, error) {
+	s.changes++
+
+	return s.playlist, nil
+}
+
+func (s *playlistStore) ReorderPlaylist(_ context.Context, _ int64, _ []int64) (*models.Playlist, error) {
+	s.changes++
+
+	return s.playlist, nil
+}
+
+func (s *playlistStore) DuplicatePlaylist(_ context.Context, _, userID int64, _ string) (*models.Playlist, error) {
+	return &models.Playlist{PlaylistID: 2, OwnerID: userID}, nil
+}
+
+func TestPlaylistOwnership(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	requests := []struct {
+		method, target, body string
+	}{
+		{http.MethodPatch, "/playlists/1", `{"name":"renamed"}`},
+		{http.MethodDelete, "/playlists/1", ``},
+		{http.MethodPost, "/playlists/1/songs", `{"song_id":1}`},
+		{http.MethodDelete, "/playlists/1/songs/1", ``},
+		{http.MethodPut, "/playlists/1/order", `{"song_ids":[1]}`},
+	}
+
+	tests := []struct {
+		name    string
+		apiKey  string
+		status  int
+		changed bool
+	}{
+		{name: "owner", apiKey: "tt_owner", status: http.StatusOK, changed: true},
+		{name: "another user", apiKey: "tt_other", status: http.StatusForbidden},
+		{name: "anonymous", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		for _, req := range requests {
+			t.Run(tt.name+" "+req.method+" "+req.target, func(t *testing.T) {
+				store := &playlistStore{playlist: &models.Playlist{PlaylistID: 1, OwnerID: 1}}
+				r := newPlaylistRouter(store, log)
+
+				w := sendPlaylistRequest(r, req.method, req.target, req.body, tt.apiKey)
+
+				if w.Code != tt.status {
+					t.Errorf("status = %d, want %d, body %s", w.Code, tt.status, w.Body)
+				}
+
+				if changed := store.changes > 0; changed != tt.changed {
+					t.Errorf("playlist changed = %v, want %v", changed, tt.changed)
+				}
+			})
+		}
+	}
+}
+
+func TestPlaylistNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := &playlistStore{playlist: &models.Playlist{PlaylistID: 1, OwnerID: 1}}
+	r := newPlaylistRouter(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if w := sendPlaylistRequest(r, http.MethodDelete, "/playlists/2", ``, "tt_owner"); w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	if w := sendPlaylistRequest(r, http.MethodDelete, "/playlists/x", ``, "tt_owner"); w.Code != http.StatusBadRequest {
+		t.Errorf("status for an invalid id = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestDuplicateAnotherUsersPlaylist(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := &playlistStore{playlist: &models.Playlist{PlaylistID: 1, OwnerID: 1}}
+	r := newPlaylistRouter(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	w := sendPlaylistRequest(r, http.MethodPost, "/playlists/1/duplicate", `{}`, "tt_other")
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusCreated)
+	}
+
+	if !strings.Contains(w.Body.String(), `"owner_id":2`) {
+		t.Errorf("copy %s is not owned by the caller", w.Body)
+	}
+}
+
+func newPlaylistRouter(store *playlistStore, log *slog.Logger) *gin.Engine {
+	h := &Handler{db: store, log: log}
+
+	r := gin.New()
+	r.Use(auth.New(store, nil, log, auth.Options{AnonymousRole: auth.RoleReader}).Middleware())
+
+	r.PATCH("/playlists/:id", h.UpdatePlaylist(time.Second))
+	r.DELETE("/playlists/:id", h.DeletePlaylist(time.Second))
+	r.POST("/playlists/:id/duplicate", h.DuplicatePlaylist(time.Second))
+	r.POST("/playlists/:id/songs", h.AddPlaylistSong(time.Second))
+	r.DELETE("/playlists/:id/songs/:song_id", h.RemovePlaylistSong(time.Second))
+	r.PUT("/playlists/:id/order", h.ReorderPlaylist(time.Second))
+
+	return r
+}
+
+func sendPlaylistRequest(r http.Handler, method, target, body, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	if apiKey != "" {
+		req.Header.Set(auth.APIKeyHeader, apiKey)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	return w
+}
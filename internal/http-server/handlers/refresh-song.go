@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"time"
+)
+
+// RefreshSong godoc
+// @Summary Refresh song data from your api
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Success 200 {object} models.RefreshSongResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 408 {object} ErrResponse
+// @Failure 502 {object} ErrResponse
+// @Failure 503 {object} ErrResponse "Song info service is unavailable or rate limited, see Retry-After"
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/{id}/refresh [post]
+func (h *Handler) RefreshSong(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.RefreshSong"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		idStr := c.Param("id")
+		if idStr == "" {
+			log.Debug("id is empty")
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is empty"))
+
+			return
+		}
+
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			log.Debug("id is invalid", slog.Int("ID", id))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		log.Debug("id is valid", slog.Int("songID", id))
+
+		changes, err := h.refresh.RefreshSong(ctx, id, storage.ChangeSourceManual)
+		if err != nil {
+			if errors.Is(err, storage.ErrSongNotFound) {
+				log.Debug(err.Error())
+
+				c.JSON(http.StatusNotFound, ErrResp("song not found"))
+
+				return
+			}
+
+			log.Error("failed to refresh song", sl.Err(err))
+
+			songInfoErr(c, err)
+
+			return
+		}
+
+		log.Debug("song refreshed", slog.Int("songID", id), slog.Any("changes", changes))
+
+		c.JSON(http.StatusOK, models.RefreshSongResponse{
+			SongID:  id,
+			Changes: changes,
+		})
+	}
+}
+
+// GetSongChanges godoc
+// @Summary Get song change history
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Success 200 {object} models.SongChangesResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /song/{id}/changes [get]
+func (h *Handler) GetSongChanges(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetSongChanges"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		idStr := c.Param("id")
+		if idStr == "" {
+			log.Debug("id is empty")
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is empty"))
+
+			return
+		}
+
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			log.Debug("id is invalid", slog.Int("ID", id))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		if _, err := h.db.GetSong(ctx, id); err != nil {
+			if errors.Is(err, storage.ErrSongNotFound) {
+				log.Debug(err.Error())
+
+				c.JSON(http.StatusNotFound, ErrResp("song not found"))
+
+				return
+			}
+
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		changes, err := h.db.GetSongChanges(ctx, id)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("song changes sent", slog.Int("songID", id), slog.Int("count", len(changes)))
+
+		c.JSON(http.StatusOK, models.SongChangesResponse{
+			SongID:  id,
+			Changes: changes,
+		})
+	}
+}
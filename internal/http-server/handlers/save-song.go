@@ -2,14 +2,19 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"log/slog"
+	"math"
 	"net/http"
-	"errors"
+	"strconv"
 	your_api "test_task/internal/clients/your-api"
 	"test_task/internal/lib/l/sl"
+	"test_task/internal/metadata"
 	"test_task/internal/models"
 	"test_task/internal/storage"
+	"test_task/pkg/e"
+	"test_task/pkg/songlink"
 	"time"
 )
 
@@ -20,13 +25,23 @@ type SaveSongRequest struct {
 
 // SaveSong godoc
 // @Summary Save song
+// @Description With async=true the song is saved in the pending state and filled in from your api in the background
 // @Accept json
 // @Produce json
 // @Param song body SaveSongRequest true "Group and Song name"
+// @Param async query bool false "Enrich the song in the background"
+// @Param Idempotency-Key header string false "Retries with the same key get the first response replayed"
 // @Success 200 {object} models.SaveSongResponse
+// @Success 202 {object} models.SaveSongAsyncResponse
 // @Success 404 {object} ErrResponse
 // @Failure 400 {object} ErrResponse
+// @Failure 409 {object} ErrResponse "Request with the same Idempotency-Key is in progress"
+// @Failure 422 {object} ErrResponse "Idempotency-Key was used with a different request"
+// @Failure 502 {object} ErrResponse
+// @Failure 503 {object} ErrResponse "Song info service is unavailable or rate limited, see Retry-After"
 // @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /song [post]
 func (h *Handler) SaveSong(ctxTimeout time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -52,100 +67,195 @@ func (h *Handler) SaveSong(ctxTimeout time.Duration) gin.HandlerFunc {
 
 		log.Debug("request body decoded", slog.Any("req", req))
 
-		groupID, groupExists, err := h.db.GroupExists(ctx, req.Group)
+		async := c.Query("async") == "true"
+
+		// concurrent saves of the same song share one upstream call and one insert
+		key := saveSongKey(req.Group, req.Song, async)
+
+		res, shared, err := h.saveFlight.Do(key, func() (saveSongResult, error) {
+			return h.saveSong(ctx, log, req, async)
+		})
 		if err != nil {
-			log.Error("failed to check if group exists", sl.Err(err))
+			log.Error("failed to save song", sl.Err(err), slog.Bool("shared", shared))
 
-			c.Status(http.StatusInternalServerError)
+			songInfoErr(c, err)
 
 			return
 		}
 
-		if groupExists {
-			log.Debug("group already exists", slog.Int64("groupID", groupID))
+		if shared {
+			log.Debug("result of a concurrent save sent", slog.Any("resp", res.body))
+		}
+
+		c.JSON(res.status, res.body)
+	}
+}
 
-			songID, songExists, err := h.db.SongExists(ctx, req.Song, groupID)
-			if err != nil {
-				log.Error("failed to check if song exists", sl.Err(err))
+type saveSongResult struct {
+	status int
+	body   any
+}
 
-				c.Status(http.StatusInternalServerError)
+// saveSongKey normalizes the names the same way the storage lookups do. Sync and async saves
+// answer differently, so they do not share calls.
+func saveSongKey(group, song string, async bool) string {
+	return strconv.FormatBool(async) + "\x00" + storage.NormalizeName(group) + "\x00" + storage.NormalizeName(song)
+}
 
-				return
-			}
+func (h *Handler) saveSong(ctx context.Context, log *slog.Logger, req SaveSongRequest, async bool) (saveSongResult, error) {
+	const fn = "handlers.saveSong"
 
-			if songExists {
-				log.Debug("existing data sent",
-					slog.Int64(req.Group, groupID),
-					slog.Int64(req.Song, songID))
+	groupID, groupExists, err := h.db.GroupExists(ctx, req.Group)
+	if err != nil {
+		return saveSongResult{}, e.Wrap(fn, err)
+	}
+
+	if groupExists {
+		log.Debug("group already exists", slog.Int64("groupID", groupID))
 
-				c.JSON(http.StatusOK, models.SaveSongResponse{
+		songID, songExists, err := h.db.SongExists(ctx, req.Song, groupID)
+		if err != nil {
+			return saveSongResult{}, e.Wrap(fn, err)
+		}
+
+		if songExists {
+			log.Debug("existing data sent",
+				slog.Int64(req.Group, groupID),
+				slog.Int64(req.Song, songID))
+
+			return saveSongResult{
+				status: http.StatusOK,
+				body: models.SaveSongResponse{
 					GroupID: groupID,
 					SongID:  songID,
-				})
-
-				return
-			}
+				},
+			}, nil
 		}
+	}
+
+	if async {
+		return h.saveSongAsync(ctx, log, req, groupID, groupExists)
+	}
 
-		resp, err := h.yourApi.GetSongInfo(ctx, req.Group, req.Song)
+	resp, err := h.meta.GetSongInfo(ctx, req.Group, req.Song)
+	if err != nil {
+		return saveSongResult{}, e.Wrap(fn, err)
+	}
+
+	log.Debug("song info received", slog.Any("resp", resp))
+
+	if !groupExists {
+		groupID, err = h.db.SaveGroup(ctx, req.Group)
 		if err != nil {
-			log.Error("failed to get song info", sl.Err(err))
+			return saveSongResult{}, e.Wrap(fn, err)
+		}
 
-			switch {
-			case errors.Is(err, context.DeadlineExceeded):
-				c.JSON(http.StatusRequestTimeout, ErrResp("request took too long"))
+		log.Debug("group saved", slog.Int64(req.Group, groupID))
+	}
 
-			case errors.Is(err, your_api.ErrBadRequest):
-				c.JSON(http.StatusBadRequest, ErrResp("bad request"))
+	releaseDate, _ := time.Parse("02.01.2006", resp.ReleaseDate)
+	link := songlink.Normalize(resp.Link)
+
+	songInfo := &storage.SongInfo{
+		Song:       req.Song,
+		Date:       releaseDate,
+		Text:       resp.Text,
+		Link:       link.URL,
+		Platform:   link.Platform,
+		ExternalID: link.ExternalID,
+		GroupID:    groupID,
+	}
 
-			default:
-				c.Status(http.StatusInternalServerError)
-			}
+	songID, err := h.db.SaveSong(ctx, songInfo)
+	if err != nil {
+		return saveSongResult{}, e.Wrap(fn, err)
+	}
 
-			return
+	log.Debug("data save",
+		slog.Int64(req.Group, groupID),
+		slog.Int64(req.Song, songID))
+
+	return saveSongResult{
+		status: http.StatusOK,
+		body: models.SaveSongResponse{
+			GroupID: groupID,
+			SongID:  songID,
+		},
+	}, nil
+}
+
+func (h *Handler) saveSongAsync(ctx context.Context, log *slog.Logger, req SaveSongRequest, groupID int64, groupExists bool) (saveSongResult, error) {
+	const fn = "handlers.saveSongAsync"
+
+	var err error
+
+	if !groupExists {
+		groupID, err = h.db.SaveGroup(ctx, req.Group)
+		if err != nil {
+			return saveSongResult{}, e.Wrap(fn, err)
 		}
 
-		log.Debug("your api response body decoded", slog.Any("resp", resp))
+		log.Debug("group saved", slog.Int64(req.Group, groupID))
+	}
 
-		if !groupExists {
-			groupID, err = h.db.SaveGroup(ctx, req.Group)
-			if err != nil {
-				log.Error("failed to save group", sl.Err(err))
+	songInfo := &storage.SongInfo{
+		Song:    req.Song,
+		Status:  storage.SongStatusPending,
+		GroupID: groupID,
+	}
 
-				c.Status(http.StatusInternalServerError)
+	songID, err := h.db.SaveSong(ctx, songInfo)
+	if err != nil {
+		return saveSongResult{}, e.Wrap(fn, err)
+	}
 
-				return
-			}
+	jobID, err := h.db.SaveJob(ctx, songID)
+	if err != nil {
+		return saveSongResult{}, e.Wrap(fn, err)
+	}
 
-			log.Debug("group saved", slog.Int64(req.Group, groupID))
-		}
+	if err := h.enrich.Enqueue(jobID); err != nil {
+		log.Warn("job left for the sweeper", slog.Int64("jobID", jobID), sl.Err(err))
+	}
 
-		releaseDate, _ := time.Parse("02.01.2006", resp.ReleaseDate)
+	log.Debug("pending song saved",
+		slog.Int64(req.Group, groupID),
+		slog.Int64(req.Song, songID),
+		slog.Int64("jobID", jobID))
 
-		songInfo := &storage.SongInfo{
-			Song:    req.Song,
-			Date:    releaseDate,
-			Text:    resp.Text,
-			Link:    resp.Link,
+	return saveSongResult{
+		status: http.StatusAccepted,
+		body: models.SaveSongAsyncResponse{
 			GroupID: groupID,
-		}
+			SongID:  songID,
+			JobID:   jobID,
+		},
+	}, nil
+}
 
-		songID, err := h.db.SaveSong(ctx, songInfo)
-		if err != nil {
-			log.Error("failed to save song", sl.Err(err))
+// songInfoErr writes the response for a failed song info lookup.
+func songInfoErr(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		c.JSON(http.StatusRequestTimeout, ErrResp("request took too long"))
 
-			c.Status(http.StatusInternalServerError)
+	case errors.Is(err, metadata.ErrNotFound):
+		c.JSON(http.StatusBadRequest, ErrResp("bad request"))
 
-			return
+	case errors.Is(err, your_api.ErrTooManyRequests), errors.Is(err, your_api.ErrRateLimited):
+		if retryAfter := your_api.RetryAfter(err); retryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
 		}
 
-		log.Debug("data save",
-			slog.Int64(req.Group, groupID),
-			slog.Int64(req.Song, songID))
+		c.JSON(http.StatusServiceUnavailable, ErrResp("song info service is busy, try again later"))
 
-		c.JSON(http.StatusOK, models.SaveSongResponse{
-			GroupID: groupID,
-			SongID:  songID,
-		})
+	case errors.Is(err, your_api.ErrCircuitOpen):
+		c.JSON(http.StatusServiceUnavailable, ErrResp("song info service is unavailable"))
+
+	case errors.Is(err, your_api.ErrInvalidResponse):
+		c.JSON(http.StatusBadGateway, ErrResp("song info service returned an invalid response"))
+
+	default:
+		c.Status(http.StatusInternalServerError)
 	}
 }
@@ -0,0 +1,48 @@
+package handlers
+
+import "testing"
+
+func TestSaveSongKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		a, b       [2]string
+		asyncA     bool
+		asyncB     bool
+		wantShared bool
+	}{
+		{
+			name:       "same names",
+			a:          [2]string{"Muse", "Supermassive Black Hole"},
+			b:          [2]string{"Muse", "Supermassive Black Hole"},
+			wantShared: true,
+		},
+		{
+			name:       "case and whitespace differ",
+			a:          [2]string{"Foo Fighters", "Everlong"},
+			b:          [2]string{" foo  FIGHTERS", "everlong\t"},
+			wantShared: true,
+		},
+		{
+			name:   "sync and async",
+			a:      [2]string{"Muse", "Uprising"},
+			b:      [2]string{"Muse", "Uprising"},
+			asyncB: true,
+		},
+		{
+			name: "group and song are not concatenated",
+			a:    [2]string{"ab", "c"},
+			b:    [2]string{"a", "bc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := saveSongKey(tt.a[0], tt.a[1], tt.asyncA)
+			b := saveSongKey(tt.b[0], tt.b[1], tt.asyncB)
+
+			if shared := a == b; shared != tt.wantShared {
+				t.Errorf("keys %q and %q shared = %v, want %v", a, b, shared, tt.wantShared)
+			}
+		})
+	}
+}
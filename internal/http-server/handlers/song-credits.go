@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"time"
+)
+
+type SongCreditRequest struct {
+	ArtistID int64  `json:"artist_id" binding:"required"`
+	Role     string `json:"role" binding:"required" example:"featured"`
+}
+
+// GetSongCredits godoc
+// @Summary Get credited artists of the song
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Success 200 {object} models.SongCreditsResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /song/{id}/credits [get]
+func (h *Handler) GetSongCredits(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetSongCredits"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		if _, err := h.db.GetSong(ctx, id); err != nil {
+			if errors.Is(err, storage.ErrSongNotFound) {
+				log.Debug(err.Error())
+
+				c.JSON(http.StatusNotFound, ErrResp("song not found"))
+
+				return
+			}
+
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		credits, err := h.db.GetSongCredits(ctx, id)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("song credits sent", slog.Int("songID", id), slog.Int("count", len(credits)))
+
+		c.JSON(http.StatusOK, models.SongCreditsResponse{
+			SongID:  id,
+			Credits: credits,
+		})
+	}
+}
+
+// AddSongCredit godoc
+// @Summary Credit an artist on the song
+// @Description Roles: featured, songwriter, producer. Adding the same credit twice is not an error
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Param credit body SongCreditRequest true "Credit"
+// @Success 200 {object} models.SongCreditsResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/{id}/credits [post]
+func (h *Handler) AddSongCredit(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.AddSongCredit"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		var req SongCreditRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("failed to decode request", sl.Err(err))
+
+			c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+			return
+		}
+
+		if !validCreditRole(req.Role) {
+			log.Debug("credit role is invalid", slog.String("role", req.Role))
+
+			c.JSON(http.StatusBadRequest, ErrResp("credit role is invalid"))
+
+			return
+		}
+
+		if err := h.db.AddSongCredit(ctx, id, req.ArtistID, req.Role); err != nil {
+			artistErr(c, log, err)
+
+			return
+		}
+
+		credits, err := h.db.GetSongCredits(ctx, id)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("song credit added", slog.Int("songID", id), slog.Int64("artistID", req.ArtistID), slog.String("role", req.Role))
+
+		c.JSON(http.StatusOK, models.SongCreditsResponse{
+			SongID:  id,
+			Credits: credits,
+		})
+	}
+}
+
+// RemoveSongCredit godoc
+// @Summary Remove a credit from the song
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Param artist_id path int true "Artist ID"
+// @Param role path string true "featured, songwriter or producer"
+// @Success 200 {object} models.SongCreditsResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/{id}/credits/{artist_id}/{role} [delete]
+func (h *Handler) RemoveSongCredit(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.RemoveSongCredit"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		artistID, ok := int64Param(c, log, "artist_id")
+		if !ok {
+			return
+		}
+
+		role := c.Param("role")
+
+		if !validCreditRole(role) {
+			log.Debug("credit role is invalid", slog.String("role", role))
+
+			c.JSON(http.StatusBadRequest, ErrResp("credit role is invalid"))
+
+			return
+		}
+
+		if err := h.db.RemoveSongCredit(ctx, id, artistID, role); err != nil {
+			artistErr(c, log, err)
+
+			return
+		}
+
+		credits, err := h.db.GetSongCredits(ctx, id)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("song credit removed", slog.Int("songID", id), slog.Int64("artistID", artistID), slog.String("role", role))
+
+		c.JSON(http.StatusOK, models.SongCreditsResponse{
+			SongID:  id,
+			Credits: credits,
+		})
+	}
+}
+
+func validCreditRole(role string) bool {
+	switch role {
+	case storage.CreditRoleFeatured, storage.CreditRoleSongwriter, storage.CreditRoleProducer:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import "testing"
+
+func TestValidCreditRole(t *testing.T) {
+	tests := []struct {
+		role string
+		want bool
+	}{
+		{"featured", true},
+		{"songwriter", true},
+		{"producer", true},
+		{"", false},
+		{"Producer", false},
+		{"drummer", false},
+	}
+
+	for _, tt := range tests {
+		if got := validCreditRole(tt.role); got != tt.want {
+			t.Errorf("validCreditRole(%q) = %v, want %v", tt.role, got, tt.want)
+		}
+	}
+}
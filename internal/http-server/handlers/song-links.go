@@ -0,0 +1,402 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"test_task/pkg/songlink"
+	"test_task/pkg/validate"
+	"time"
+)
+
+type SongLinkRequest struct {
+	Type    string `json:"type" example:"video"`
+	Label   string `json:"label"`
+	URL     string `json:"url" binding:"required"`
+	Primary bool   `json:"primary"`
+}
+
+type SongLinkUpdateRequest struct {
+	Type    *string `json:"type,omitempty"`
+	Label   *string `json:"label,omitempty"`
+	URL     *string `json:"url,omitempty"`
+	Primary *bool   `json:"primary,omitempty"`
+}
+
+// GetSongLinks godoc
+// @Summary Get song links
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Success 200 {object} models.SongLinksResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /song/{id}/links [get]
+func (h *Handler) GetSongLinks(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetSongLinks"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		if _, err := h.db.GetSong(ctx, id); err != nil {
+			if errors.Is(err, storage.ErrSongNotFound) {
+				log.Debug(err.Error())
+
+				c.JSON(http.StatusNotFound, ErrResp("song not found"))
+
+				return
+			}
+
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		links, err := h.db.GetSongLinks(ctx, id)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("song links sent", slog.Int("songID", id), slog.Int("count", len(links)))
+
+		c.JSON(http.StatusOK, models.SongLinksResponse{
+			SongID: id,
+			Links:  links,
+		})
+	}
+}
+
+// CreateSongLink godoc
+// @Summary Add a link to the song
+// @Description A primary link is also set as the song link. Types: video, streaming, purchase, lyrics, other
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Param link body SongLinkRequest true "Link"
+// @Success 201 {object} models.SongLink
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 409 {object} ErrResponse "The song already has this link"
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/{id}/links [post]
+func (h *Handler) CreateSongLink(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.CreateSongLink"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		var req SongLinkRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("failed to decode request", sl.Err(err))
+
+			c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+			return
+		}
+
+		log.Debug("request body decoded", slog.Any("req", req))
+
+		if req.Type == "" {
+			req.Type = storage.LinkTypeOther
+		}
+
+		if !validLinkType(req.Type) {
+			log.Debug("link type is invalid", slog.String("type", req.Type))
+
+			c.JSON(http.StatusBadRequest, ErrResp("link type is invalid"))
+
+			return
+		}
+
+		link, err := h.checkLink(ctx, req.URL)
+		if err != nil {
+			log.Debug("request link is invalid", slog.String("link", req.URL), sl.Err(err))
+
+			c.JSON(http.StatusBadRequest, ErrResp(linkErrMsg(err)))
+
+			return
+		}
+
+		created, err := h.db.CreateSongLink(ctx, id, &models.SongLink{
+			Type:       req.Type,
+			Label:      req.Label,
+			URL:        link.URL,
+			Platform:   link.Platform,
+			ExternalID: link.ExternalID,
+			Primary:    req.Primary,
+		})
+		if err != nil {
+			songLinkErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("song link created", slog.Int("songID", id), slog.Int64("linkID", created.LinkID))
+
+		c.JSON(http.StatusCreated, created)
+	}
+}
+
+// UpdateSongLink godoc
+// @Summary Update a song link
+// @Description Setting primary to false on the primary link clears the song link
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Param link_id path int true "Link ID"
+// @Param link body SongLinkUpdateRequest true "Update data"
+// @Success 200 {object} models.SongLink
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 409 {object} ErrResponse "The song already has this link"
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/{id}/links/{link_id} [patch]
+func (h *Handler) UpdateSongLink(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.UpdateSongLink"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, linkID, ok := songLinkIDs(c, log)
+		if !ok {
+			return
+		}
+
+		var req SongLinkUpdateRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("failed to decode request", sl.Err(err))
+
+			c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+			return
+		}
+
+		log.Debug("request body decoded", slog.Any("req", req))
+
+		update := &storage.SongLinkUpdate{
+			Label:   req.Label,
+			Primary: req.Primary,
+		}
+
+		if req.Type != nil {
+			if !validLinkType(*req.Type) {
+				log.Debug("link type is invalid", slog.String("type", *req.Type))
+
+				c.JSON(http.StatusBadRequest, ErrResp("link type is invalid"))
+
+				return
+			}
+
+			update.Type = *req.Type
+		}
+
+		if req.URL != nil {
+			link, err := h.checkLink(ctx, *req.URL)
+			if err != nil {
+				log.Debug("request link is invalid", slog.String("link", *req.URL), sl.Err(err))
+
+				c.JSON(http.StatusBadRequest, ErrResp(linkErrMsg(err)))
+
+				return
+			}
+
+			update.URL = link.URL
+			update.Platform = link.Platform
+			update.ExternalID = link.ExternalID
+		}
+
+		updated, err := h.db.UpdateSongLink(ctx, id, linkID, update)
+		if err != nil {
+			songLinkErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("song link updated", slog.Int("songID", id), slog.Int64("linkID", linkID))
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// DeleteSongLink godoc
+// @Summary Delete a song link
+// @Description Deleting the primary link clears the song link
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Param link_id path int true "Link ID"
+// @Success 200 {object} models.DeleteSongLinkResp
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/{id}/links/{link_id} [delete]
+func (h *Handler) DeleteSongLink(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.DeleteSongLink"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, linkID, ok := songLinkIDs(c, log)
+		if !ok {
+			return
+		}
+
+		if err := h.db.DeleteSongLink(ctx, id, linkID); err != nil {
+			songLinkErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("song link deleted", slog.Int("songID", id), slog.Int64("linkID", linkID))
+
+		c.JSON(http.StatusOK, models.DeleteSongLinkResp{
+			Message: "link deleted",
+			SongID:  id,
+			LinkID:  linkID,
+		})
+	}
+}
+
+// checkLink canonicalizes the link and checks that it is reachable.
+func (h *Handler) checkLink(ctx context.Context, raw string) (songlink.Info, error) {
+	link, err := songlink.Parse(raw)
+	if err != nil {
+		return songlink.Info{}, err
+	}
+
+	if err := h.links.Check(ctx, link.URL); err != nil {
+		return songlink.Info{}, err
+	}
+
+	return link, nil
+}
+
+func linkErrMsg(err error) string {
+	if errors.Is(err, validate.ErrForbiddenAddress) {
+		return "link points to a forbidden address"
+	}
+
+	return "link is invalid"
+}
+
+func songLinkIDs(c *gin.Context, log *slog.Logger) (int, int64, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+		c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+		return 0, 0, false
+	}
+
+	linkID, err := strconv.ParseInt(c.Param("link_id"), 10, 64)
+	if err != nil {
+		log.Debug("link id is invalid", slog.String("link_id", c.Param("link_id")))
+
+		c.JSON(http.StatusBadRequest, ErrResp("link id is invalid"))
+
+		return 0, 0, false
+	}
+
+	return id, linkID, true
+}
+
+func songLinkErr(c *gin.Context, log *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, storage.ErrSongNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("song not found"))
+
+	case errors.Is(err, storage.ErrLinkNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("link not found"))
+
+	case errors.Is(err, storage.ErrLinkExists):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusConflict, ErrResp("the song already has this link"))
+
+	case errors.Is(err, storage.ErrNoFieldsUpdate):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusBadRequest, ErrResp("no fields to update"))
+
+	default:
+		log.Error(err.Error())
+
+		c.Status(http.StatusInternalServerError)
+	}
+}
+
+func validLinkType(linkType string) bool {
+	switch linkType {
+	case storage.LinkTypeVideo, storage.LinkTypeStreaming, storage.LinkTypePurchase,
+		storage.LinkTypeLyrics, storage.LinkTypeOther:
+		return true
+	}
+
+	return false
+}
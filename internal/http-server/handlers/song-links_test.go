@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"fmt"
+	"test_task/pkg/validate"
+	"testing"
+)
+
+func TestValidLinkType(t *testing.T) {
+	tests := []struct {
+		linkType string
+		want     bool
+	}{
+		{"video", true},
+		{"streaming", true},
+		{"purchase", true},
+		{"lyrics", true},
+		{"other", true},
+		{"", false},
+		{"Video", false},
+		{"download", false},
+	}
+
+	for _, tt := range tests {
+		if got := validLinkType(tt.linkType); got != tt.want {
+			t.Errorf("validLinkType(%q) = %v, want %v", tt.linkType, got, tt.want)
+		}
+	}
+}
+
+func TestLinkErrMsg(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("%w: 127.0.0.1", validate.ErrForbiddenAddress), "link points to a forbidden address"},
+		{validate.ErrInvalidURL, "link is invalid"},
+		{validate.ErrTooManyRedirects, "link is invalid"},
+	}
+
+	for _, tt := range tests {
+		if got := linkErrMsg(tt.err); got != tt.want {
+			t.Errorf("linkErrMsg(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"time"
+)
+
+const (
+	defaultRelatedDepth = 3
+	maxRelatedDepth     = 10
+)
+
+type SongRelationRequest struct {
+	RelatedSongID int64  `json:"related_song_id" binding:"required"`
+	Type          string `json:"type" binding:"required" example:"cover_of"`
+}
+
+// GetRelatedSongs godoc
+// @Summary Get the relationship graph of the song
+// @Description Songs are the songs reachable from the song through at most depth relations in any direction, relations are the edges between them
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Param depth query int false "1 to 10, 3 by default"
+// @Success 200 {object} models.RelatedSongsResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /song/{id}/related [get]
+func (h *Handler) GetRelatedSongs(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetRelatedSongs"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		depth := defaultRelatedDepth
+
+		if depthStr := c.Query("depth"); depthStr != "" {
+			depth, err = strconv.Atoi(depthStr)
+			if err != nil || depth < 1 || depth > maxRelatedDepth {
+				log.Debug("depth is invalid", slog.String("depth", depthStr))
+
+				c.JSON(http.StatusBadRequest, ErrResp("depth must be from 1 to "+strconv.Itoa(maxRelatedDepth)))
+
+				return
+			}
+		}
+
+		related, err := h.db.GetRelatedSongs(ctx, id, depth)
+		if err != nil {
+			relationErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("related songs sent", slog.Int("songID", id), slog.Int("songs", len(related.Songs)), slog.Int("relations", len(related.Relations)))
+
+		c.JSON(http.StatusOK, related)
+	}
+}
+
+// AddSongRelation godoc
+// @Summary Mark the song as a cover, remix, live version or sample of another song
+// @Description Types: cover_of, remix_of, live_version_of, sample_of. Adding the same relation twice is not an error
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Param relation body SongRelationRequest true "Relation"
+// @Success 200 {object} models.SongRelationsResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/{id}/relations [post]
+func (h *Handler) AddSongRelation(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.AddSongRelation"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		var req SongRelationRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("failed to decode request", sl.Err(err))
+
+			c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+			return
+		}
+
+		if !validRelationType(req.Type) {
+			log.Debug("relation type is invalid", slog.String("type", req.Type))
+
+			c.JSON(http.StatusBadRequest, ErrResp("relation type is invalid"))
+
+			return
+		}
+
+		if req.RelatedSongID == int64(id) {
+			log.Debug("song is related to itself", slog.Int("songID", id))
+
+			c.JSON(http.StatusBadRequest, ErrResp("song cannot be related to itself"))
+
+			return
+		}
+
+		if err := h.db.AddSongRelation(ctx, id, req.RelatedSongID, req.Type); err != nil {
+			relationErr(c, log, err)
+
+			return
+		}
+
+		relations, err := h.db.GetSongRelations(ctx, id)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("song relation added", slog.Int("songID", id), slog.Int64("relatedSongID", req.RelatedSongID), slog.String("type", req.Type))
+
+		c.JSON(http.StatusOK, models.SongRelationsResponse{
+			SongID:    id,
+			Relations: relations,
+		})
+	}
+}
+
+// RemoveSongRelation godoc
+// @Summary Remove a relation of the song to another song
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Param related_id path int true "Related song ID"
+// @Param type path string true "cover_of, remix_of, live_version_of or sample_of"
+// @Success 200 {object} models.SongRelationsResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/{id}/relations/{related_id}/{type} [delete]
+func (h *Handler) RemoveSongRelation(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.RemoveSongRelation"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		relatedID, ok := int64Param(c, log, "related_id")
+		if !ok {
+			return
+		}
+
+		relationType := c.Param("type")
+
+		if !validRelationType(relationType) {
+			log.Debug("relation type is invalid", slog.String("type", relationType))
+
+			c.JSON(http.StatusBadRequest, ErrResp("relation type is invalid"))
+
+			return
+		}
+
+		if err := h.db.RemoveSongRelation(ctx, id, relatedID, relationType); err != nil {
+			relationErr(c, log, err)
+
+			return
+		}
+
+		relations, err := h.db.GetSongRelations(ctx, id)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("song relation removed", slog.Int("songID", id), slog.Int64("relatedSongID", relatedID), slog.String("type", relationType))
+
+		c.JSON(http.StatusOK, models.SongRelationsResponse{
+			SongID:    id,
+			Relations: relations,
+		})
+	}
+}
+
+func validRelationType(relationType string) bool {
+	switch relationType {
+	case storage.RelationCoverOf, storage.RelationRemixOf, storage.RelationLiveVersionOf, storage.RelationSampleOf:
+		return true
+	}
+
+	return false
+}
+
+func relationErr(c *gin.Context, log *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, storage.ErrSongNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("song not found"))
+
+	case errors.Is(err, storage.ErrRelationNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("relation not found"))
+
+	default:
+		log.Error(err.Error())
+
+		c.Status(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import "testing"
+
+func TestValidRelationType(t *testing.T) {
+	tests := []struct {
+		relationType string
+		want         bool
+	}{
+		{"cover_of", true},
+		{"remix_of", true},
+		{"live_version_of", true},
+		{"sample_of", true},
+		{"", false},
+		{"cover", false},
+		{"Cover_Of", false},
+		{"covered_by", false},
+	}
+
+	for _, tt := range tests {
+		if got := validRelationType(tt.relationType); got != tt.want {
+			t.Errorf("validRelationType(%q) = %v, want %v", tt.relationType, got, tt.want)
+		}
+	}
+}
@@ -8,10 +8,11 @@ import (
 	"net/http"
 	"reflect"
 	"strconv"
+	"strings"
 	"test_task/internal/lib/l/sl"
 	"test_task/internal/models"
 	"test_task/internal/storage"
-	"test_task/pkg/validate"
+	"test_task/pkg/songlink"
 	"time"
 )
 
@@ -20,6 +21,18 @@ type SongUpdateRequest struct {
 	ReleaseDate *string `json:"release_date,omitempty"`
 	SongText    *string `json:"song_text,omitempty"`
 	Link        *string `json:"link,omitempty"`
+	// AlbumID 0 removes the song from its album, the album must belong to the song group.
+	AlbumID *int64 `json:"album_id,omitempty"`
+	// TrackNumber 0 clears the number.
+	TrackNumber *int `json:"track_number,omitempty"`
+	// Duration is in seconds. Zero Duration and BPM or empty Key and Language clear the value.
+	Duration *int     `json:"duration,omitempty" example:"215"`
+	BPM      *float64 `json:"bpm,omitempty" example:"124.5"`
+	// Key is a musical key like C, F#m or Bb.
+	Key *string `json:"key,omitempty" example:"F#m"`
+	// Language is a two-letter ISO 639-1 code.
+	Language *string `json:"language,omitempty" example:"en"`
+	Explicit *bool   `json:"explicit,omitempty"`
 }
 
 type parsedSongUpdateReq struct {
@@ -38,7 +51,10 @@ type parsedSongUpdateReq struct {
 // @Success 200 {object} models.SongUpdateResponse
 // @Success 404 {object} ErrResponse
 // @Failure 400 {object} ErrResponse
+// @Failure 409 {object} ErrResponse "Group already has a song with this name"
 // @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /song/{id} [patch]
 func (h *Handler) SongUpdate(ctxTimeout time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -99,21 +115,89 @@ func (h *Handler) SongUpdate(ctxTimeout time.Duration) gin.HandlerFunc {
 			}
 		}
 
+		var link songlink.Info
+
 		if !(preq.link == "" || preq.link == "NULL") {
-			if !validate.Link(ctx, preq.link) {
-				log.Debug("request link is invalid", slog.String("link", preq.link))
+			link, err = h.checkLink(ctx, preq.link)
+			if err != nil {
+				log.Debug("request link is invalid", slog.String("link", preq.link), sl.Err(err))
+
+				c.JSON(http.StatusBadRequest, ErrResp(linkErrMsg(err)))
+
+				return
+			}
+
+			preq.link = link.URL
+		}
+
+		if req.TrackNumber != nil && *req.TrackNumber < 0 {
+			log.Debug("track number is negative", slog.Int("track_number", *req.TrackNumber))
+
+			c.JSON(http.StatusBadRequest, ErrResp("track number is invalid"))
+
+			return
+		}
+
+		if req.Duration != nil && (*req.Duration < 0 || *req.Duration > maxDuration) {
+			log.Debug("duration is invalid", slog.Int("duration", *req.Duration))
+
+			c.JSON(http.StatusBadRequest, ErrResp("duration is invalid"))
+
+			return
+		}
+
+		if req.BPM != nil && (*req.BPM < 0 || *req.BPM > maxBPM) {
+			log.Debug("bpm is invalid", slog.Float64("bpm", *req.BPM))
+
+			c.JSON(http.StatusBadRequest, ErrResp("bpm is invalid"))
 
-				c.JSON(http.StatusBadRequest, ErrResp("link is invalid"))
+			return
+		}
+
+		if req.Key != nil && *req.Key != "" {
+			key, ok := musicalKey(*req.Key)
+			if !ok {
+				log.Debug("key is invalid", slog.String("key", *req.Key))
+
+				c.JSON(http.StatusBadRequest, ErrResp("key is invalid, correct format: C, F#m or Bb"))
+
+				return
+			}
+
+			req.Key = &key
+		}
+
+		if req.Language != nil && *req.Language != "" {
+			language, ok := languageCode(*req.Language)
+			if !ok {
+				log.Debug("language is invalid", slog.String("language", *req.Language))
+
+				c.JSON(http.StatusBadRequest, ErrResp("language is invalid, correct format: two-letter ISO 639-1 code"))
 
 				return
 			}
+
+			req.Language = &language
+		}
+
+		if req.AlbumID != nil && *req.AlbumID != 0 && !h.checkSongAlbum(ctx, c, log, id, *req.AlbumID) {
+			return
 		}
 
 		songInfo := &storage.SongInfo{
-			Song: preq.songName,
-			Date: releaseDate,
-			Text: preq.songText,
-			Link: preq.link,
+			Song:        preq.songName,
+			Date:        releaseDate,
+			Text:        preq.songText,
+			Link:        preq.link,
+			Platform:    link.Platform,
+			ExternalID:  link.ExternalID,
+			AlbumID:     req.AlbumID,
+			TrackNumber: req.TrackNumber,
+			Duration:    req.Duration,
+			BPM:         req.BPM,
+			Key:         req.Key,
+			Language:    req.Language,
+			Explicit:    req.Explicit,
 		}
 
 		if err := h.db.UpdateSong(ctx, id, songInfo); err != nil {
@@ -129,6 +213,12 @@ func (h *Handler) SongUpdate(ctxTimeout time.Duration) gin.HandlerFunc {
 
 				c.JSON(http.StatusNotFound, ErrResp("song not found"))
 
+				return
+			case errors.Is(err, storage.ErrSongExists):
+				log.Debug(err.Error())
+
+				c.JSON(http.StatusConflict, ErrResp("group already has a song with this name"))
+
 				return
 			default:
 				log.Debug(err.Error())
@@ -151,10 +241,110 @@ func (h *Handler) SongUpdate(ctxTimeout time.Duration) gin.HandlerFunc {
 				ReleaseDate: preq.releaseDate,
 				SongText:    preq.songText,
 				Link:        preq.link,
+				Platform:    link.Platform,
+				ExternalID:  link.ExternalID,
+				AlbumID:     req.AlbumID,
+				TrackNumber: req.TrackNumber,
+				Duration:    req.Duration,
+				BPM:         req.BPM,
+				Key:         req.Key,
+				Language:    req.Language,
+				Explicit:    req.Explicit,
 			}})
 	}
 }
 
+// checkSongAlbum checks that the album exists and belongs to the group of the song.
+func (h *Handler) checkSongAlbum(ctx context.Context, c *gin.Context, log *slog.Logger, songID int, albumID int64) bool {
+	album, err := h.db.GetAlbum(ctx, albumID)
+	if err != nil {
+		albumErr(c, log, err)
+
+		return false
+	}
+
+	song, err := h.db.GetSong(ctx, songID)
+	if err != nil {
+		if errors.Is(err, storage.ErrSongNotFound) {
+			log.Debug(err.Error())
+
+			c.JSON(http.StatusNotFound, ErrResp("song not found"))
+
+			return false
+		}
+
+		log.Error(err.Error())
+
+		c.Status(http.StatusInternalServerError)
+
+		return false
+	}
+
+	if album.GroupID != song.GroupID {
+		log.Debug("album belongs to another group", slog.Int64("albumID", albumID), slog.Int64("groupID", song.GroupID))
+
+		c.JSON(http.StatusBadRequest, ErrResp("album belongs to another group"))
+
+		return false
+	}
+
+	return true
+}
+
+const (
+	// maxDuration is 24 hours in seconds.
+	maxDuration = 24 * 60 * 60
+	maxBPM      = 999
+)
+
+// musicalKey returns the key in the C, F#m or Bb notation, the note letter is case-insensitive.
+func musicalKey(key string) (string, bool) {
+	key = strings.TrimSpace(key)
+
+	if key == "" {
+		return "", false
+	}
+
+	note := strings.ToUpper(key[:1])
+
+	if note < "A" || note > "G" {
+		return "", false
+	}
+
+	rest := key[1:]
+
+	if strings.HasPrefix(rest, "#") || strings.HasPrefix(rest, "b") {
+		note += rest[:1]
+		rest = rest[1:]
+	}
+
+	switch rest {
+	case "":
+		return note, true
+	case "m":
+		return note + "m", true
+	}
+
+	return "", false
+}
+
+// languageCode returns the lowercased two-letter ISO 639-1 code.
+func languageCode(language string) (string, bool) {
+	language = strings.ToLower(strings.TrimSpace(language))
+
+	if len(language) != 2 {
+		return "", false
+	}
+
+	for _, r := range language {
+		if r < 'a' || r > 'z' {
+			return "", false
+		}
+	}
+
+	return language, true
+}
+
 func parsingReq(input interface{}) parsedSongUpdateReq {
 	var pr parsedSongUpdateReq
 	val := reflect.ValueOf(input)
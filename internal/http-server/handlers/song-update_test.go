@@ -0,0 +1,56 @@
+package handlers
+
+import "testing"
+
+func TestMusicalKey(t *testing.T) {
+	tests := []struct {
+		key    string
+		want   string
+		wantOK bool
+	}{
+		{"C", "C", true},
+		{"c", "C", true},
+		{"F#m", "F#m", true},
+		{"f#m", "F#m", true},
+		{"Bb", "Bb", true},
+		{"bbm", "Bbm", true},
+		{" Am ", "Am", true},
+		{"", "", false},
+		{"H", "", false},
+		{"C##", "", false},
+		{"CM", "", false},
+		{"Cmaj", "", false},
+		{"#", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := musicalKey(tt.key)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("musicalKey(%q) = %q, %v, want %q, %v", tt.key, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestLanguageCode(t *testing.T) {
+	tests := []struct {
+		language string
+		want     string
+		wantOK   bool
+	}{
+		{"en", "en", true},
+		{"RU", "ru", true},
+		{" de ", "de", true},
+		{"", "", false},
+		{"e", "", false},
+		{"eng", "", false},
+		{"e1", "", false},
+		{"ру", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := languageCode(tt.language)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("languageCode(%q) = %q, %v, want %q, %v", tt.language, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	tagMatchAll = "all"
+	tagMatchAny = "any"
+)
+
+const maxTagLength = 64
+
+type TagsRequest struct {
+	Genres []string `json:"genres" example:"rock,alternative"`
+	Tags   []string `json:"tags" example:"90s"`
+}
+
+// GetTags godoc
+// @Summary Get genres and tags
+// @Produce  json
+// @Param kind query string false "genre or tag"
+// @Success 200 {object} models.TagsResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /tags [get]
+func (h *Handler) GetTags(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetTags"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		kind := c.Query("kind")
+
+		if kind != "" && kind != storage.TagKindGenre && kind != storage.TagKindTag {
+			log.Debug("tag kind is invalid", slog.String("kind", kind))
+
+			c.JSON(http.StatusBadRequest, ErrResp("kind is invalid"))
+
+			return
+		}
+
+		tags, err := h.db.GetTags(ctx, kind)
+		if err != nil {
+			log.Error(err.Error())
+
+			c.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Debug("tags sent", slog.String("kind", kind), slog.Int("count", len(tags)))
+
+		c.JSON(http.StatusOK, models.TagsResponse{
+			Tags: tags,
+		})
+	}
+}
+
+// GetSongTags godoc
+// @Summary Get genres and tags of the song
+// @Description Inherited are the genres and tags of the song group
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Success 200 {object} models.SongTagsResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /song/{id}/tags [get]
+func (h *Handler) GetSongTags(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetSongTags"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		tags, err := h.db.GetSongTags(ctx, id)
+		if err != nil {
+			tagErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("song tags sent", slog.Int("songID", id))
+
+		c.JSON(http.StatusOK, tags)
+	}
+}
+
+// SetSongTags godoc
+// @Summary Replace genres and tags of the song
+// @Description Names are lowercased, unknown genres and tags are created
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Param tags body TagsRequest true "Genres and tags"
+// @Success 200 {object} models.SongTagsResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/{id}/tags [put]
+func (h *Handler) SetSongTags(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.SetSongTags"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Debug("id is invalid", slog.String("id", c.Param("id")))
+
+			c.JSON(http.StatusBadRequest, ErrResp("id is invalid"))
+
+			return
+		}
+
+		set, ok := tagSetFromRequest(c, log)
+		if !ok {
+			return
+		}
+
+		if err := h.db.SetSongTags(ctx, id, set); err != nil {
+			tagErr(c, log, err)
+
+			return
+		}
+
+		tags, err := h.db.GetSongTags(ctx, id)
+		if err != nil {
+			tagErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("song tags set", slog.Int("songID", id), slog.Int("genres", len(set.Genres)), slog.Int("tags", len(set.Tags)))
+
+		c.JSON(http.StatusOK, tags)
+	}
+}
+
+// GetGroupTags godoc
+// @Summary Get genres and tags of a group
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Success 200 {object} models.GroupTagsResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Router /groups/{id}/tags [get]
+func (h *Handler) GetGroupTags(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.GetGroupTags"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		groupID, ok := int64Param(c, log, "id")
+		if !ok {
+			return
+		}
+
+		tags, err := h.db.GetGroupTags(ctx, groupID)
+		if err != nil {
+			tagErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("group tags sent", slog.Int64("groupID", groupID))
+
+		c.JSON(http.StatusOK, tags)
+	}
+}
+
+// SetGroupTags godoc
+// @Summary Replace genres and tags of a group
+// @Description The songs of the group inherit its genres and tags. Names are lowercased, unknown genres and tags are created
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Param tags body TagsRequest true "Genres and tags"
+// @Success 200 {object} models.GroupTagsResponse
+// @Success 404 {object} ErrResponse
+// @Failure 400 {object} ErrResponse
+// @Failure 500
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /groups/{id}/tags [put]
+func (h *Handler) SetGroupTags(ctxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.SetGroupTags"
+
+		log := h.log.With(
+			slog.String("fn", fn),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+		defer cancel()
+
+		groupID, ok := int64Param(c, log, "id")
+		if !ok {
+			return
+		}
+
+		set, ok := tagSetFromRequest(c, log)
+		if !ok {
+			return
+		}
+
+		if err := h.db.SetGroupTags(ctx, groupID, set); err != nil {
+			tagErr(c, log, err)
+
+			return
+		}
+
+		tags, err := h.db.GetGroupTags(ctx, groupID)
+		if err != nil {
+			tagErr(c, log, err)
+
+			return
+		}
+
+		log.Debug("group tags set", slog.Int64("groupID", groupID), slog.Int("genres", len(set.Genres)), slog.Int("tags", len(set.Tags)))
+
+		c.JSON(http.StatusOK, tags)
+	}
+}
+
+func tagSetFromRequest(c *gin.Context, log *slog.Logger) (*models.TagSet, bool) {
+	var req TagsRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Error("failed to decode request", sl.Err(err))
+
+		c.JSON(http.StatusBadRequest, ErrResp("incorrect json"))
+
+		return nil, false
+	}
+
+	set := &models.TagSet{
+		Genres: tagNames(req.Genres),
+		Tags:   tagNames(req.Tags),
+	}
+
+	for _, name := range append(set.Genres, set.Tags...) {
+		if utf8.RuneCountInString(name) > maxTagLength {
+			log.Debug("tag is too long", slog.String("tag", name))
+
+			c.JSON(http.StatusBadRequest, ErrResp("tag is longer than "+strconv.Itoa(maxTagLength)+" characters"))
+
+			return nil, false
+		}
+	}
+
+	return set, true
+}
+
+// tagNames splits the comma-separated values into lowercased tag names without duplicates.
+func tagNames(values []string) []string {
+	names := []string{}
+	seen := make(map[string]bool)
+
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+
+			if name == "" || seen[name] {
+				continue
+			}
+
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func tagErr(c *gin.Context, log *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, storage.ErrSongNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("song not found"))
+
+	case errors.Is(err, storage.ErrGroupNotFound):
+		log.Debug(err.Error())
+
+		c.JSON(http.StatusNotFound, ErrResp("group not found"))
+
+	default:
+		log.Error(err.Error())
+
+		c.Status(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{"no values", nil, []string{}},
+		{"single", []string{"rock"}, []string{"rock"}},
+		{"comma-separated", []string{"rock, Indie ,pop"}, []string{"rock", "indie", "pop"}},
+		{"repeated parameter", []string{"rock", "pop"}, []string{"rock", "pop"}},
+		{"duplicates in another case", []string{"Rock,rock", "ROCK"}, []string{"rock"}},
+		{"empty names", []string{" , ,rock,"}, []string{"rock"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tagNames(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tagNames(%q) = %q, want %q", tt.values, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strings"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"test_task/pkg/jwt"
+	"time"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+
+	RoleReader = "reader"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+
+	keyPrefix    = "tt_"
+	principalKey = "auth.principal"
+)
+
+const (
+	MethodAPIKey    = "api_key"
+	MethodJWT       = "jwt"
+	MethodAnonymous = "anonymous"
+)
+
+// roleRanks orders the roles, every role has the permissions of the lower ones.
+var roleRanks = map[string]int{
+	RoleReader: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+type Store interface {
+	AuthenticateAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error)
+}
+
+// Principal is the authenticated caller.
+type Principal struct {
+	// Subject is prefixed with the auth method, so a token subject never matches an api key.
+	Subject string
+	Role    string
+	Method  string
+}
+
+type Options struct {
+	// AnonymousRole is given to requests without credentials, empty rejects them.
+	AnonymousRole string
+	// BootstrapKey is an admin key set in the config, used to issue the first keys.
+	BootstrapKey string
+}
+
+type Authenticator struct {
+	store    Store
+	verifier *jwt.Verifier
+	log      *slog.Logger
+	opts     Options
+}
+
+func New(store Store, verifier *jwt.Verifier, log *slog.Logger, opts Options) *Authenticator {
+	return &Authenticator{
+		store:    store,
+		verifier: verifier,
+		log:      log,
+		opts:     opts,
+	}
+}
+
+type errResponse struct {
+	Error string `json:"error"`
+}
+
+// ValidRole reports whether role is one of reader, editor and admin.
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+
+	return ok
+}
+
+// Middleware authenticates the request by the X-API-Key header or by the JWT in the
+// Authorization: Bearer header. Requests with invalid credentials are rejected with 401,
+// requests without credentials get the anonymous role. Use Require to restrict routes.
+func (a *Authenticator) Middleware() gin.HandlerFunc {
+	fn := func(c *gin.Context) {
+		log := a.log.With(
+			slog.String("fn", "auth.Middleware"),
+			slog.String("client_ip", c.ClientIP()),
+		)
+
+		principal, err := a.authenticate(c)
+		if err != nil {
+			log.Debug("authentication failed", sl.Err(err))
+
+			if errors.Is(err, errInternal) {
+				c.AbortWithStatus(http.StatusInternalServerError)
+
+				return
+			}
+
+			c.Header("WWW-Authenticate", `Bearer realm="test_task"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, errResponse{"invalid credentials"})
+
+			return
+		}
+
+		if principal != nil {
+			c.Set(principalKey, principal)
+		}
+
+		c.Next()
+	}
+
+	return fn
+}
+
+var errInternal = errors.New("internal error")
+
+func (a *Authenticator) authenticate(c *gin.Context) (*Principal, error) {
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		return a.authenticateKey(c.Request.Context(), key)
+	}
+
+	if header := c.GetHeader("Authorization"); header != "" {
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || !a.verifier.Enabled() {
+			return nil, errors.New("unsupported authorization")
+		}
+
+		claims, err := a.verifier.Verify(strings.TrimSpace(token))
+		if err != nil {
+			return nil, err
+		}
+
+		if !ValidRole(claims.Role) {
+			return nil, errors.New("unknown role in token")
+		}
+
+		return &Principal{Subject: subject(MethodJWT, claims.Subject), Role: claims.Role, Method: MethodJWT}, nil
+	}
+
+	if a.opts.AnonymousRole == "" {
+		return nil, nil
+	}
+
+	return &Principal{Subject: MethodAnonymous, Role: a.opts.AnonymousRole, Method: MethodAnonymous}, nil
+}
+
+func (a *Authenticator) authenticateKey(ctx context.Context, key string) (*Principal, error) {
+	hash := HashKey(key)
+
+	if a.opts.BootstrapKey != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(HashKey(a.opts.BootstrapKey))) == 1 {
+		return &Principal{Subject: subject(MethodAPIKey, "bootstrap"), Role: RoleAdmin, Method: MethodAPIKey}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	apiKey, err := a.store.AuthenticateAPIKey(ctx, hash)
+	if err != nil {
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			return nil, err
+		}
+
+		a.log.Error("failed to authenticate api key", sl.Err(err))
+
+		return nil, errInternal
+	}
+
+	return &Principal{Subject: subject(MethodAPIKey, fmt.Sprint(apiKey.KeyID)), Role: apiKey.Role, Method: MethodAPIKey}, nil
+}
+
+// subject prefixes the id of the caller with the auth method.
+func subject(method, id string) string {
+	return method + ":" + id
+}
+
+// Require lets through only the requests with the role or a higher one,
+// 401 is returned without credentials and 403 for a lower role of an authenticated caller.
+func Require(role string) gin.HandlerFunc {
+	fn := func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok || (principal.Method == MethodAnonymous && roleRanks[principal.Role] < roleRanks[role]) {
+			c.Header("WWW-Authenticate", `Bearer realm="test_task"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, errResponse{"authentication required"})
+
+			return
+		}
+
+		if roleRanks[principal.Role] < roleRanks[role] {
+			c.AbortWithStatusJSON(http.StatusForbidden, errResponse{"insufficient role"})
+
+			return
+		}
+
+		c.Next()
+	}
+
+	return fn
+}
+
+// Authenticated rejects anonymous requests with 401, it is used by the routes
+// working with the data of the caller.
+func Authenticated() gin.HandlerFunc {
+	fn := func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok || principal.Method == MethodAnonymous {
+			c.Header("WWW-Authenticate", `Bearer realm="test_task"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, errResponse{"authentication required"})
+
+			return
+		}
+
+		c.Next()
+	}
+
+	return fn
+}
+
+func GetPrincipal(c *gin.Context) (*Principal, bool) {
+	v, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+
+	principal, ok := v.(*Principal)
+
+	return principal, ok
+}
+
+// GenerateKey returns a new api key, its prefix shown in key listings and its hash to store.
+func GenerateKey() (key, prefix, hash string, err error) {
+	buf := make([]byte, 32)
+
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+
+	key = keyPrefix + hex.EncodeToString(buf)
+
+	return key, key[:len(keyPrefix)+8], HashKey(key), nil
+}
+
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
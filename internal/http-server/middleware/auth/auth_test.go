@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"test_task/pkg/jwt"
+	"testing"
+	"time"
+)
+
+var secret = []byte("secret")
+
+type keyStore map[string]*models.APIKey
+
+func (s keyStore) AuthenticateAPIKey(_ context.Context, keyHash string) (*models.APIKey, error) {
+	if key, ok := s[keyHash]; ok {
+		return key, nil
+	}
+
+	return nil, storage.ErrAPIKeyNotFound
+}
+
+func signHS256(t *testing.T, claims map[string]any) string {
+	t.Helper()
+
+	encode := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signed := encode(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encode(claims)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestMiddlewarePrincipal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	a := New(keyStore{
+		HashKey("tt_key"): {KeyID: 1, Role: RoleEditor},
+	}, jwt.NewVerifier(jwt.Options{HMACSecret: secret}), slog.New(slog.NewTextHandler(io.Discard, nil)), Options{
+		AnonymousRole: RoleReader,
+		BootstrapKey:  "tt_bootstrap",
+	})
+
+	token := signHS256(t, map[string]any{"sub": "1", "role": RoleAdmin, "exp": time.Now().Add(time.Hour).Unix()})
+
+	tests := []struct {
+		name    string
+		header  [2]string
+		status  int
+		subject string
+		role    string
+	}{
+		{
+			name:    "api key",
+			header:  [2]string{APIKeyHeader, "tt_key"},
+			status:  http.StatusOK,
+			subject: "api_key:1",
+			role:    RoleEditor,
+		},
+		{
+			name:    "bootstrap key",
+			header:  [2]string{APIKeyHeader, "tt_bootstrap"},
+			status:  http.StatusOK,
+			subject: "api_key:bootstrap",
+			role:    RoleAdmin,
+		},
+		{
+			name:    "jwt with the id of an api key",
+			header:  [2]string{"Authorization", "Bearer " + token},
+			status:  http.StatusOK,
+			subject: "jwt:1",
+			role:    RoleAdmin,
+		},
+		{
+			name:    "anonymous",
+			status:  http.StatusOK,
+			subject: MethodAnonymous,
+			role:    RoleReader,
+		},
+		{
+			name:   "unknown api key",
+			header: [2]string{APIKeyHeader, "tt_unknown"},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "invalid token",
+			header: [2]string{"Authorization", "Bearer " + token + "x"},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "basic auth",
+			header: [2]string{"Authorization", "Basic dXNlcjpwYXNz"},
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *Principal
+
+			r := gin.New()
+			r.GET("/", a.Middleware(), func(c *gin.Context) {
+				got, _ = GetPrincipal(c)
+
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header[0] != "" {
+				req.Header.Set(tt.header[0], tt.header[1])
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			if got == nil || got.Subject != tt.subject || got.Role != tt.role {
+				t.Errorf("principal = %+v, want subject %q and role %q", got, tt.subject, tt.role)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"test_task/internal/http-server/middleware/auth"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/storage"
+	"time"
+)
+
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+type Store interface {
+	CreateIdempotencyKey(ctx context.Context, key, requestHash string, expiredBefore time.Time, lease time.Duration) (*storage.IdempotencyRecord, bool, error)
+	SaveIdempotentResponse(ctx context.Context, key string, statusCode int, contentType string, body []byte) error
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+}
+
+type errResponse struct {
+	Error string `json:"error"`
+}
+
+// bodyWriter keeps a copy of the response body.
+type bodyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Middleware stores the first response to a request with the Idempotency-Key header for window
+// and replays it on retries with the same key. Reusing a key with a different payload is answered
+// with 422, a retry that arrives while the first request is still processed with 409. A request
+// that did not finish within lease, e.g. because the process was killed, no longer holds the key.
+// Only final outcomes are stored: 2xx and 4xx except 408, 409 and 429. After any other response
+// the key is released, so the request can be retried with the same key.
+// Keys are scoped by the caller, so different callers may use the same key.
+// Requests without the header are passed through.
+func Middleware(store Store, log *slog.Logger, window, lease time.Duration) gin.HandlerFunc {
+	fn := func(c *gin.Context) {
+		key := c.GetHeader(Header)
+		if key == "" {
+			c.Next()
+
+			return
+		}
+
+		log := log.With(
+			slog.String("fn", "idempotency.Middleware"),
+			slog.String("idempotency_key", key),
+		)
+
+		if len(key) > maxKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, errResponse{"idempotency key is too long"})
+
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			log.Error("failed to read request body", sl.Err(err))
+
+			c.AbortWithStatusJSON(http.StatusBadRequest, errResponse{"failed to read request body"})
+
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key = scopedKey(c, key)
+
+		hash := requestHash(c.Request, body)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		record, created, err := store.CreateIdempotencyKey(ctx, key, hash, time.Now().Add(-window), lease)
+		if err != nil {
+			log.Error("failed to create idempotency key", sl.Err(err))
+
+			c.AbortWithStatus(http.StatusInternalServerError)
+
+			return
+		}
+
+		if !created {
+			switch {
+			case record.RequestHash != hash:
+				log.Debug("idempotency key reused with a different payload")
+
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, errResponse{"idempotency key was used with a different request"})
+
+			case record.StatusCode == 0:
+				log.Debug("request with the idempotency key is in progress")
+
+				c.AbortWithStatusJSON(http.StatusConflict, errResponse{"request with this idempotency key is in progress"})
+
+			default:
+				log.Debug("stored response replayed", slog.Int("status", record.StatusCode))
+
+				c.Header(ReplayedHeader, "true")
+				c.Data(record.StatusCode, record.ContentType, record.Body)
+				c.Abort()
+			}
+
+			return
+		}
+
+		w := &bodyWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		defer func() {
+			if r := recover(); r != nil {
+				releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer releaseCancel()
+
+				if err := store.DeleteIdempotencyKey(releaseCtx, key); err != nil {
+					log.Error("failed to release idempotency key", sl.Err(err))
+				}
+
+				panic(r)
+			}
+		}()
+
+		c.Next()
+
+		saveCtx, saveCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer saveCancel()
+
+		status := w.Status()
+
+		if !final(status) {
+			if err := store.DeleteIdempotencyKey(saveCtx, key); err != nil {
+				log.Error("failed to release idempotency key", sl.Err(err))
+			}
+
+			return
+		}
+
+		if err := store.SaveIdempotentResponse(saveCtx, key, status, w.Header().Get("Content-Type"), w.body.Bytes()); err != nil {
+			log.Error("failed to save idempotent response", sl.Err(err))
+		}
+	}
+
+	return fn
+}
+
+// scopedKey prefixes the key with the subject of the caller. The header value cannot contain
+// a newline, so the stored key is unambiguous.
+func scopedKey(c *gin.Context, key string) string {
+	var subject string
+
+	if principal, ok := auth.GetPrincipal(c); ok {
+		subject = principal.Subject
+	}
+
+	return subject + "\n" + key
+}
+
+// final reports whether retrying the request cannot change the response with the status.
+func final(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+		return false
+	}
+
+	return status >= http.StatusOK && status < http.StatusInternalServerError
+}
+
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+
+	h.Write([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery + "\n"))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
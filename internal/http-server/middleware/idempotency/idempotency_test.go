@@ -0,0 +1,327 @@
+package idempotency
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"test_task/internal/http-server/middleware/auth"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"testing"
+	"time"
+)
+
+type memStore struct {
+	mu      sync.Mutex
+	records map[string]*storage.IdempotencyRecord
+}
+
+func newMemStore() *memStore {
+	return &memStore{records: make(map[string]*storage.IdempotencyRecord)}
+}
+
+func (s *memStore) CreateIdempotencyKey(_ context.Context, key, requestHash string, _ time.Time, _ time.Duration) (*storage.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok {
+		copied := *record
+
+		return &copied, false, nil
+	}
+
+	s.records[key] = &storage.IdempotencyRecord{Key: key, RequestHash: requestHash}
+
+	return nil, true, nil
+}
+
+func (s *memStore) SaveIdempotentResponse(_ context.Context, key string, statusCode int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok {
+		record.StatusCode = statusCode
+		record.ContentType = contentType
+		record.Body = body
+	}
+
+	return nil
+}
+
+func (s *memStore) DeleteIdempotencyKey(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+
+	return nil
+}
+
+func (s *memStore) stored(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.records[key]
+
+	return ok
+}
+
+func newRouter(store Store, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.POST("/song", Middleware(store, slog.New(slog.NewTextHandler(io.Discard, nil)), time.Hour, time.Minute), handler)
+
+	return r
+}
+
+func post(r http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/song", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestMiddlewareReplay(t *testing.T) {
+	calls := 0
+
+	r := newRouter(newMemStore(), func(c *gin.Context) {
+		calls++
+
+		c.JSON(http.StatusOK, gin.H{"call": calls})
+	})
+
+	first := post(r, "key-1", `{"song":"a"}`)
+	second := post(r, "key-1", `{"song":"a"}`)
+
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want once", calls)
+	}
+
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Errorf("replayed %d %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+
+	if second.Header().Get(ReplayedHeader) != "true" {
+		t.Errorf("%s header is missing", ReplayedHeader)
+	}
+
+	if first.Header().Get(ReplayedHeader) != "" {
+		t.Errorf("%s header is set on the first response", ReplayedHeader)
+	}
+}
+
+func TestMiddlewareDifferentPayload(t *testing.T) {
+	r := newRouter(newMemStore(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{})
+	})
+
+	post(r, "key-1", `{"song":"a"}`)
+
+	if w := post(r, "key-1", `{"song":"b"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestMiddlewareInProgress(t *testing.T) {
+	store := newMemStore()
+
+	r := newRouter(store, func(c *gin.Context) {
+		t.Error("handler ran while the key is in progress")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/song", strings.NewReader(`{"song":"a"}`))
+
+	// the first request holds the key and has not answered yet
+	store.records["\nkey-1"] = &storage.IdempotencyRecord{Key: "\nkey-1", RequestHash: requestHash(req, []byte(`{"song":"a"}`))}
+
+	if w := post(r, "key-1", `{"song":"a"}`); w.Code != http.StatusConflict {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+func TestMiddlewareReleasesKey(t *testing.T) {
+	for _, status := range []int{
+		http.StatusRequestTimeout,
+		http.StatusConflict,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusServiceUnavailable,
+	} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			store := newMemStore()
+			calls := 0
+
+			r := newRouter(store, func(c *gin.Context) {
+				calls++
+
+				c.Status(status)
+			})
+
+			post(r, "key-1", `{}`)
+			post(r, "key-1", `{}`)
+
+			if calls != 2 {
+				t.Errorf("handler ran %d times, want the retry to run it again", calls)
+			}
+
+			if store.stored("\nkey-1") {
+				t.Error("key was not released")
+			}
+		})
+	}
+}
+
+func TestMiddlewareReleasesKeyOnPanic(t *testing.T) {
+	store := newMemStore()
+
+	r := newRouter(store, func(c *gin.Context) {
+		panic("boom")
+	})
+
+	func() {
+		defer func() {
+			if rec := recover(); rec != "boom" {
+				t.Errorf("recovered %v, want the handler panic", rec)
+			}
+		}()
+
+		post(r, "key-1", `{}`)
+	}()
+
+	if store.stored("\nkey-1") {
+		t.Error("key was not released after the panic")
+	}
+}
+
+func TestMiddlewareWithoutKey(t *testing.T) {
+	store := newMemStore()
+	calls := 0
+
+	r := newRouter(store, func(c *gin.Context) {
+		calls++
+
+		c.Status(http.StatusOK)
+	})
+
+	post(r, "", `{}`)
+	post(r, "", `{}`)
+
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want every request passed through", calls)
+	}
+
+	if w := post(r, strings.Repeat("k", maxKeyLength+1), `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("status for a long key = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+type keyStore map[string]*models.APIKey
+
+func (s keyStore) AuthenticateAPIKey(_ context.Context, keyHash string) (*models.APIKey, error) {
+	if key, ok := s[keyHash]; ok {
+		return key, nil
+	}
+
+	return nil, storage.ErrAPIKeyNotFound
+}
+
+func TestMiddlewareScopesKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	authn := auth.New(keyStore{
+		auth.HashKey("tt_first"):  {KeyID: 1, Name: "first", Role: auth.RoleEditor},
+		auth.HashKey("tt_second"): {KeyID: 2, Name: "second", Role: auth.RoleEditor},
+	}, nil, log, auth.Options{})
+
+	calls := 0
+
+	r := gin.New()
+	r.POST("/song", authn.Middleware(), Middleware(newMemStore(), log, time.Hour, time.Minute), func(c *gin.Context) {
+		calls++
+
+		c.JSON(http.StatusOK, gin.H{"call": calls})
+	})
+
+	send := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/song", strings.NewReader(`{"song":"a"}`))
+		req.Header.Set(Header, "key-1")
+		req.Header.Set(auth.APIKeyHeader, apiKey)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		return w
+	}
+
+	send("tt_first")
+
+	// the same key of another caller does not replay the first response
+	if w := send("tt_second"); w.Header().Get(ReplayedHeader) != "" || calls != 2 {
+		t.Errorf("response of another caller was replayed, handler ran %d times", calls)
+	}
+
+	if w := send("tt_first"); w.Header().Get(ReplayedHeader) != "true" || calls != 2 {
+		t.Errorf("response of the same caller was not replayed, handler ran %d times", calls)
+	}
+}
+
+func TestFinal(t *testing.T) {
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusOK, true},
+		{http.StatusCreated, true},
+		{http.StatusAccepted, true},
+		{http.StatusBadRequest, true},
+		{http.StatusNotFound, true},
+		{http.StatusUnprocessableEntity, true},
+		{http.StatusRequestTimeout, false},
+		{http.StatusConflict, false},
+		{http.StatusTooManyRequests, false},
+		{http.StatusInternalServerError, false},
+		{http.StatusBadGateway, false},
+		{http.StatusServiceUnavailable, false},
+	}
+
+	for _, tt := range tests {
+		if got := final(tt.status); got != tt.want {
+			t.Errorf("final(%d) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestRequestHash(t *testing.T) {
+	hash := func(method, target, body string) string {
+		return requestHash(httptest.NewRequest(method, target, nil), []byte(body))
+	}
+
+	base := hash(http.MethodPost, "/song?async=true", `{"song":"a"}`)
+
+	if base != hash(http.MethodPost, "/song?async=true", `{"song":"a"}`) {
+		t.Error("hash of the same request differs")
+	}
+
+	for name, other := range map[string]string{
+		"method": hash(http.MethodPut, "/song?async=true", `{"song":"a"}`),
+		"path":   hash(http.MethodPost, "/songs?async=true", `{"song":"a"}`),
+		"query":  hash(http.MethodPost, "/song", `{"song":"a"}`),
+		"body":   hash(http.MethodPost, "/song?async=true", `{"song":"b"}`),
+	} {
+		if other == base {
+			t.Errorf("hash does not depend on the %s", name)
+		}
+	}
+}
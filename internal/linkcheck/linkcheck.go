@@ -0,0 +1,157 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/storage"
+	"test_task/pkg/validate"
+	"time"
+)
+
+type Options struct {
+	Interval    time.Duration
+	MaxAge      time.Duration
+	BatchSize   int
+	Concurrency int
+}
+
+// Checker periodically checks the stored song links, both the primary ones and the ones added at /song/:id/links. On every tick it checks links
+// that were never checked or were checked more than MaxAge ago, Concurrency at a time.
+type Checker struct {
+	db        storage.Storage
+	validator *validate.LinkValidator
+	log       *slog.Logger
+	opts      Options
+	wg        sync.WaitGroup
+}
+
+func New(db storage.Storage, validator *validate.LinkValidator, log *slog.Logger, opts Options) *Checker {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+
+	return &Checker{
+		db:        db,
+		validator: validator,
+		log:       log,
+		opts:      opts,
+	}
+}
+
+// Start launches the checker, a zero Interval disables it. It stops when ctx is canceled, use Wait to wait for it.
+func (c *Checker) Start(ctx context.Context) {
+	if c.opts.Interval <= 0 {
+		return
+	}
+
+	c.wg.Add(1)
+
+	go func() {
+		defer c.wg.Done()
+
+		ticker := time.NewTicker(c.opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.checkBatch(ctx)
+			}
+		}
+	}()
+}
+
+func (c *Checker) Wait() {
+	c.wg.Wait()
+}
+
+func (c *Checker) checkBatch(ctx context.Context) {
+	log := c.log.With(slog.String("fn", "linkcheck.checkBatch"))
+
+	links, err := c.db.LinksToCheck(ctx, time.Now().Add(-c.opts.MaxAge), c.opts.BatchSize)
+	if err != nil {
+		log.Error("failed to get links to check", sl.Err(err))
+
+		return
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		broken int
+	)
+
+	sem := make(chan struct{}, c.opts.Concurrency)
+
+	for _, link := range links {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+
+			return
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			err := c.validator.Check(ctx, link.Link)
+			if ctx.Err() != nil {
+				return
+			}
+
+			status := Status(err)
+
+			if err := c.db.SetLinkStatus(ctx, link.SongID, link.LinkID, link.Link, status); err != nil {
+				log.Error("failed to set link status", slog.Int("songID", link.SongID), slog.Int64("linkID", link.LinkID), sl.Err(err))
+
+				return
+			}
+
+			if status != storage.LinkStatusOK {
+				log.Info("broken link found",
+					slog.Int("songID", link.SongID),
+					slog.Int64("linkID", link.LinkID),
+					slog.String("link", link.Link),
+					slog.String("status", status),
+					sl.Err(err))
+
+				mu.Lock()
+				broken++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	log.Debug("link check batch done", slog.Int("links", len(links)), slog.Int("broken", broken))
+}
+
+// Status maps the result of validate.LinkValidator.Check to a link status.
+func Status(err error) string {
+	switch {
+	case err == nil:
+		return storage.LinkStatusOK
+	case errors.Is(err, validate.ErrBrokenLink):
+		return storage.LinkStatusBroken
+	case errors.Is(err, validate.ErrUnreachableServer):
+		return storage.LinkStatusUnreachable
+	default:
+		return storage.LinkStatusInvalid
+	}
+}
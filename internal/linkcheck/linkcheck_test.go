@@ -0,0 +1,32 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"test_task/internal/storage"
+	"test_task/pkg/validate"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, storage.LinkStatusOK},
+		{fmt.Errorf("%w: 404", validate.ErrBrokenLink), storage.LinkStatusBroken},
+		{fmt.Errorf("%w: timeout", validate.ErrUnreachableServer), storage.LinkStatusUnreachable},
+		{validate.ErrForbiddenAddress, storage.LinkStatusInvalid},
+		{validate.ErrTooManyRedirects, storage.LinkStatusInvalid},
+		{validate.ErrInvalidURL, storage.LinkStatusInvalid},
+		{context.Canceled, storage.LinkStatusInvalid},
+		{errors.New("unknown"), storage.LinkStatusInvalid},
+	}
+
+	for _, tt := range tests {
+		if got := Status(tt.err); got != tt.want {
+			t.Errorf("Status(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package metadata
+
+import (
+	"context"
+	"log/slog"
+	"test_task/internal/lib/l/sl"
+	"test_task/pkg/e"
+)
+
+type NamedProvider struct {
+	Name     string
+	Provider MetadataProvider
+}
+
+// Chain asks providers in order and fills the fields missing in the answer of one provider
+// from the next ones, it stops as soon as all fields are filled.
+// If no provider knows the song, the error of the first provider is returned.
+type Chain struct {
+	providers []NamedProvider
+	log       *slog.Logger
+}
+
+func NewChain(log *slog.Logger, providers ...NamedProvider) *Chain {
+	return &Chain{
+		providers: providers,
+		log:       log,
+	}
+}
+
+func (c *Chain) GetSongInfo(ctx context.Context, group, song string) (*SongInfo, error) {
+	const fn = "metadata.GetSongInfo"
+
+	log := c.log.With(slog.String("fn", fn))
+
+	var (
+		res      SongInfo
+		found    bool
+		firstErr error
+	)
+
+	for _, p := range c.providers {
+		if found && res.complete() {
+			break
+		}
+
+		info, err := p.Provider.GetSongInfo(ctx, group, song)
+		if err != nil {
+			log.Debug("provider failed", slog.String("provider", p.Name), sl.Err(err))
+
+			if firstErr == nil {
+				firstErr = err
+			}
+
+			continue
+		}
+
+		res.fill(info)
+		found = true
+	}
+
+	if !found {
+		if firstErr == nil {
+			firstErr = ErrNotFound
+		}
+
+		return nil, e.Wrap(fn, firstErr)
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,176 @@
+package metadata
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"test_task/pkg/e"
+)
+
+type dirEntry struct {
+	Group string `json:"group"`
+	Song  string `json:"song"`
+	SongInfo
+}
+
+// Dir serves song info from a local directory of *.json and *.csv files loaded once on creation.
+//
+// A JSON file holds an array of objects:
+//
+//	[{"group": "Muse", "song": "Supermassive Black Hole", "releaseDate": "16.07.2006", "text": "...", "link": "...",
+//	  "album": "Black Holes and Revelations", "trackNumber": 3}]
+//
+// A CSV file has a header row with the group, song, releaseDate, text and link columns in any order,
+// the album and trackNumber columns are optional.
+// Group and song names are matched case-insensitively.
+type Dir struct {
+	songs map[string]SongInfo
+}
+
+func NewDir(path string) (*Dir, error) {
+	const fn = "metadata.NewDir"
+
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	d := &Dir{songs: make(map[string]SongInfo)}
+
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		name := filepath.Join(path, file.Name())
+
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".json":
+			err = d.loadJSON(name)
+		case ".csv":
+			err = d.loadCSV(name)
+		default:
+			continue
+		}
+
+		if err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+	}
+
+	return d, nil
+}
+
+func (d *Dir) GetSongInfo(_ context.Context, group, song string) (*SongInfo, error) {
+	const fn = "metadata.Dir.GetSongInfo"
+
+	info, ok := d.songs[dirKey(group, song)]
+	if !ok {
+		return nil, e.Wrap(fn, ErrNotFound)
+	}
+
+	return &info, nil
+}
+
+func (d *Dir) Len() int {
+	return len(d.songs)
+}
+
+func (d *Dir) loadJSON(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	var entries []dirEntry
+
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	for _, entry := range entries {
+		d.add(entry)
+	}
+
+	return nil
+}
+
+func (d *Dir) loadCSV(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	columns := make(map[string]int, len(header))
+
+	for i, column := range header {
+		columns[strings.TrimSpace(column)] = i
+	}
+
+	for _, required := range []string{"group", "song"} {
+		if _, ok := columns[required]; !ok {
+			return fmt.Errorf("%s: missing %q column", name, required)
+		}
+	}
+
+	value := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return record[i]
+		}
+
+		return ""
+	}
+
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		trackNumber, _ := strconv.Atoi(value(record, "trackNumber"))
+
+		d.add(dirEntry{
+			Group: value(record, "group"),
+			Song:  value(record, "song"),
+			SongInfo: SongInfo{
+				ReleaseDate: value(record, "releaseDate"),
+				Text:        value(record, "text"),
+				Link:        value(record, "link"),
+				Album:       value(record, "album"),
+				TrackNumber: trackNumber,
+			},
+		})
+	}
+
+	return nil
+}
+
+func (d *Dir) add(entry dirEntry) {
+	if entry.Group == "" || entry.Song == "" {
+		return
+	}
+
+	d.songs[dirKey(entry.Group, entry.Song)] = entry.SongInfo
+}
+
+func dirKey(group, song string) string {
+	return strings.ToLower(strings.TrimSpace(group)) + "\x00" + strings.ToLower(strings.TrimSpace(song))
+}
@@ -0,0 +1,60 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+)
+
+// SongInfo is the song info returned by a metadata provider, the release date is in the DD.MM.YYYY format.
+// Album and TrackNumber are optional, they are empty when the provider does not know the album.
+type SongInfo struct {
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+	Album       string `json:"album,omitempty"`
+	TrackNumber int    `json:"trackNumber,omitempty"`
+}
+
+type MetadataProvider interface {
+	GetSongInfo(ctx context.Context, group, song string) (*SongInfo, error)
+}
+
+var ErrNotFound = errors.New("song info not found")
+
+type noCacheKey struct{}
+
+// WithoutCache returns a context that asks caching providers to skip cached entries and fetch fresh data.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+func CacheDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(noCacheKey{}).(bool)
+
+	return disabled
+}
+
+func (i *SongInfo) complete() bool {
+	return i.ReleaseDate != "" && i.Text != "" && i.Link != ""
+}
+
+// fill sets the empty fields of i from other.
+func (i *SongInfo) fill(other *SongInfo) {
+	if i.ReleaseDate == "" {
+		i.ReleaseDate = other.ReleaseDate
+	}
+
+	if i.Text == "" {
+		i.Text = other.Text
+	}
+
+	if i.Link == "" {
+		i.Link = other.Link
+	}
+
+	// the track number belongs to the album, so they are taken together
+	if i.Album == "" {
+		i.Album = other.Album
+		i.TrackNumber = other.TrackNumber
+	}
+}
@@ -1,17 +1,94 @@
 package models
 
+import "time"
+
 type Song struct {
 	SongID      int64  `json:"song_id"`
 	SongName    string `json:"song_name"`
 	ReleaseDate string `json:"release_date"`
 	SongText    string `json:"song_text"`
 	Link        string `json:"link"`
+	Status      string `json:"status"`
+	LinkStatus  string `json:"link_status"`
+	Platform    string `json:"platform"`
+	ExternalID  string `json:"external_id"`
+
+	AlbumID     int64  `json:"album_id,omitempty"`
+	AlbumTitle  string `json:"album,omitempty"`
+	TrackNumber int    `json:"track_number,omitempty"`
+
+	// Duration is in seconds.
+	Duration int     `json:"duration,omitempty"`
+	BPM      float64 `json:"bpm,omitempty"`
+	Key      string  `json:"key,omitempty" example:"F#m"`
+	Language string  `json:"language,omitempty" example:"en"`
+	Explicit bool    `json:"explicit"`
+
+	// Attributes are the custom attributes of the song, see AttributeDefinition.
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+
+	// Genres and Tags include the ones inherited from the group.
+	Genres []string `json:"genres,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+
+	AverageRating float64 `json:"average_rating"`
+	RatingsCount  int     `json:"ratings_count"`
+	// Favorited is set only for authenticated users.
+	Favorited bool `json:"favorited,omitempty"`
+
+	// Links are all links of the song, Link is a copy of the primary one.
+	Links []SongLink `json:"links"`
+}
+
+type SongLink struct {
+	LinkID     int64     `json:"link_id"`
+	Type       string    `json:"type"`
+	Label      string    `json:"label"`
+	URL        string    `json:"url"`
+	Platform   string    `json:"platform"`
+	ExternalID string    `json:"external_id"`
+	Primary    bool      `json:"primary"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type SongLinksResponse struct {
+	SongID int        `json:"song_id"`
+	Links  []SongLink `json:"links"`
+}
+
+type DeleteSongLinkResp struct {
+	Message string `json:"message"`
+	SongID  int    `json:"song_id"`
+	LinkID  int64  `json:"link_id"`
 }
 
 type Group struct {
 	GroupID   int64  `json:"group_id"`
 	GroupName string `json:"group_name"`
 	SongInfo  []Song `json:"song_info"`
+
+	// Albums are filled only in the albums view of the library, SongInfo then holds the songs without an album.
+	Albums []Album `json:"albums,omitempty"`
+
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+type Album struct {
+	AlbumID     int64  `json:"album_id"`
+	GroupID     int64  `json:"group_id"`
+	Title       string `json:"title"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	SongsCount  int    `json:"songs_count"`
+	Songs       []Song `json:"songs,omitempty"`
+}
+
+type AlbumsResponse struct {
+	Albums []Album `json:"albums"`
+}
+
+type DeleteAlbumResp struct {
+	Message string `json:"message"`
+	AlbumID int64  `json:"album_id"`
 }
 
 type DeleteSongResp struct {
@@ -21,6 +98,8 @@ type DeleteSongResp struct {
 
 type GetLibraryResponse struct {
 	Library []Group `json:"library"`
+	// Facets are returned only on request.
+	Facets *Facets `json:"facets,omitempty"`
 }
 
 type SongTextResp struct {
@@ -34,6 +113,323 @@ type SaveSongResponse struct {
 	SongID  int64 `json:"song_Id"`
 }
 
+type SaveSongAsyncResponse struct {
+	GroupID int64 `json:"group_Id"`
+	SongID  int64 `json:"song_Id"`
+	JobID   int64 `json:"job_Id"`
+}
+
+type Job struct {
+	JobID     int64     `json:"job_id"`
+	SongID    int64     `json:"song_id"`
+	Status    string    `json:"status"`
+	Attempts  int       `json:"attempts"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SongChange struct {
+	Field     string    `json:"field"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
+	Source    string    `json:"source"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type RefreshSongResponse struct {
+	SongID  int          `json:"song_id"`
+	Changes []SongChange `json:"changes"`
+}
+
+type SongChangesResponse struct {
+	SongID  int          `json:"song_id"`
+	Changes []SongChange `json:"changes"`
+}
+
+type BrokenLink struct {
+	SongID        int64     `json:"song_id"`
+	SongName      string    `json:"song_name"`
+	GroupID       int64     `json:"group_id"`
+	GroupName     string    `json:"group_name"`
+	LinkID        int64     `json:"link_id"`
+	Primary       bool      `json:"primary"`
+	Link          string    `json:"link"`
+	LinkStatus    string    `json:"link_status"`
+	LinkCheckedAt time.Time `json:"link_checked_at"`
+}
+
+type BrokenLinksResponse struct {
+	BrokenLinks []BrokenLink `json:"broken_links"`
+}
+
+type User struct {
+	UserID    int64     `json:"user_id"`
+	Subject   string    `json:"subject"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MeResponse struct {
+	User
+	Role string `json:"role"`
+}
+
+type FavoriteSong struct {
+	SongID        int64     `json:"song_id"`
+	SongName      string    `json:"song_name"`
+	GroupID       int64     `json:"group_id"`
+	GroupName     string    `json:"group_name"`
+	Link          string    `json:"link"`
+	AverageRating float64   `json:"average_rating"`
+	RatingsCount  int       `json:"ratings_count"`
+	UserRating    int       `json:"user_rating,omitempty"`
+	FavoritedAt   time.Time `json:"favorited_at"`
+}
+
+type FavoritesResponse struct {
+	Favorites []FavoriteSong `json:"favorites"`
+}
+
+type FavoriteResponse struct {
+	SongID    int  `json:"song_id"`
+	Favorited bool `json:"favorited"`
+}
+
+// RatingResponse holds the rating of the user, 0 if the user has not rated the song, and the song totals.
+type RatingResponse struct {
+	SongID        int     `json:"song_id"`
+	Rating        int     `json:"rating"`
+	AverageRating float64 `json:"average_rating"`
+	RatingsCount  int     `json:"ratings_count"`
+}
+
+type Playlist struct {
+	PlaylistID  int64     `json:"playlist_id"`
+	OwnerID     int64     `json:"owner_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	SongsCount  int       `json:"songs_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Songs are filled only when a single playlist is requested.
+	Songs []PlaylistSong `json:"songs,omitempty"`
+}
+
+type PlaylistSong struct {
+	Position  int       `json:"position"`
+	SongID    int64     `json:"song_id"`
+	SongName  string    `json:"song_name"`
+	GroupID   int64     `json:"group_id"`
+	GroupName string    `json:"group_name"`
+	Link      string    `json:"link"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+type PlaylistsResponse struct {
+	Playlists []Playlist `json:"playlists"`
+}
+
+type DeletePlaylistResp struct {
+	Message    string `json:"message"`
+	PlaylistID int64  `json:"playlist_id"`
+}
+
+type Artist struct {
+	ArtistID  int64     `json:"artist_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Groups and Credits are filled only when a single artist is requested.
+	Groups  []ArtistGroup  `json:"groups,omitempty"`
+	Credits []ArtistCredit `json:"credits,omitempty"`
+}
+
+type ArtistGroup struct {
+	GroupID    int64  `json:"group_id"`
+	GroupName  string `json:"group_name"`
+	JoinedYear int    `json:"joined_year,omitempty"`
+	LeftYear   int    `json:"left_year,omitempty"`
+}
+
+type ArtistCredit struct {
+	SongID    int64  `json:"song_id"`
+	SongName  string `json:"song_name"`
+	GroupName string `json:"group_name"`
+	Role      string `json:"role"`
+}
+
+type ArtistsResponse struct {
+	Artists []Artist `json:"artists"`
+}
+
+type DeleteArtistResp struct {
+	Message  string `json:"message"`
+	ArtistID int64  `json:"artist_id"`
+}
+
+type GroupMember struct {
+	ArtistID   int64  `json:"artist_id"`
+	Name       string `json:"name"`
+	JoinedYear int    `json:"joined_year,omitempty"`
+	LeftYear   int    `json:"left_year,omitempty"`
+}
+
+type GroupMembersResponse struct {
+	GroupID int64         `json:"group_id"`
+	Members []GroupMember `json:"members"`
+}
+
+type SongCredit struct {
+	ArtistID int64  `json:"artist_id"`
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+}
+
+type SongCreditsResponse struct {
+	SongID  int          `json:"song_id"`
+	Credits []SongCredit `json:"credits"`
+}
+
+type Tag struct {
+	TagID int64  `json:"tag_id"`
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	// SongsCount counts the songs tagged directly or through their group.
+	SongsCount int `json:"songs_count"`
+}
+
+type TagsResponse struct {
+	Tags []Tag `json:"tags"`
+}
+
+type TagSet struct {
+	Genres []string `json:"genres"`
+	Tags   []string `json:"tags"`
+}
+
+type SongTagsResponse struct {
+	SongID int      `json:"song_id"`
+	Genres []string `json:"genres"`
+	Tags   []string `json:"tags"`
+	// Inherited are the tags of the song group.
+	Inherited TagSet `json:"inherited"`
+}
+
+type GroupTagsResponse struct {
+	GroupID int64    `json:"group_id"`
+	Genres  []string `json:"genres"`
+	Tags    []string `json:"tags"`
+}
+
+// Facet is the number of the filtered songs having the tag.
+type Facet struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type Facets struct {
+	Genres []Facet `json:"genres"`
+	Tags   []Facet `json:"tags"`
+}
+
+// AttributeDefinition declares a custom attribute of songs and groups.
+type AttributeDefinition struct {
+	Name        string    `json:"name" example:"mood"`
+	Type        string    `json:"type" example:"string"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type AttributeDefinitionsResponse struct {
+	Attributes []AttributeDefinition `json:"attributes"`
+}
+
+type DeleteAttributeResp struct {
+	Message string `json:"message"`
+	Name    string `json:"name"`
+}
+
+type SongAttributesResponse struct {
+	SongID     int                    `json:"song_id"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// SongRelation means that the song is a cover, remix, live version or sample of the related song.
+type SongRelation struct {
+	SongID        int64     `json:"song_id"`
+	RelatedSongID int64     `json:"related_song_id"`
+	Type          string    `json:"type" example:"cover_of"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type SongRelationsResponse struct {
+	SongID    int            `json:"song_id"`
+	Relations []SongRelation `json:"relations"`
+}
+
+type RelatedSong struct {
+	SongID    int64  `json:"song_id"`
+	SongName  string `json:"song_name"`
+	GroupID   int64  `json:"group_id"`
+	GroupName string `json:"group_name"`
+	// Distance is the number of relations between the song and the requested one.
+	Distance int `json:"distance"`
+}
+
+// RelatedSongsResponse is the graph of the songs related to the song, Songs are the nodes
+// and Relations are the edges between them.
+type RelatedSongsResponse struct {
+	SongID    int            `json:"song_id"`
+	Songs     []RelatedSong  `json:"songs"`
+	Relations []SongRelation `json:"relations"`
+}
+
+type GroupAttributesResponse struct {
+	GroupID    int64                  `json:"group_id"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+type APIKey struct {
+	KeyID      int64      `json:"key_id"`
+	Name       string     `json:"name"`
+	Role       string     `json:"role"`
+	Prefix     string     `json:"prefix"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IssueAPIKeyResponse is the only response that contains the key itself.
+type IssueAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type APIKeysResponse struct {
+	Keys []APIKey `json:"keys"`
+}
+
+type RevokeAPIKeyResponse struct {
+	Message string `json:"message"`
+	KeyID   int64  `json:"key_id"`
+}
+
+type CacheStatsResponse struct {
+	Enabled      bool    `json:"enabled"`
+	Entries      int     `json:"entries"`
+	Capacity     int     `json:"capacity"`
+	Hits         uint64  `json:"hits"`
+	NegativeHits uint64  `json:"negative_hits"`
+	Misses       uint64  `json:"misses"`
+	HitRatio     float64 `json:"hit_ratio"`
+}
+
+type FlushCacheResponse struct {
+	Message string `json:"message"`
+	Flushed int    `json:"flushed"`
+}
+
 type SongUpdateResponse struct {
 	SongID     int        `json:"song_id"`
 	UpdateInfo UpdateInfo `json:"update_info"`
@@ -44,4 +440,14 @@ type UpdateInfo struct {
 	ReleaseDate string `json:"release_date,omitempty"`
 	SongText    string `json:"song_text,omitempty"`
 	Link        string `json:"link,omitempty"`
+	Platform    string `json:"platform,omitempty"`
+	ExternalID  string `json:"external_id,omitempty"`
+	AlbumID     *int64 `json:"album_id,omitempty"`
+	TrackNumber *int   `json:"track_number,omitempty"`
+
+	Duration *int     `json:"duration,omitempty"`
+	BPM      *float64 `json:"bpm,omitempty"`
+	Key      *string  `json:"key,omitempty"`
+	Language *string  `json:"language,omitempty"`
+	Explicit *bool    `json:"explicit,omitempty"`
 }
@@ -0,0 +1,200 @@
+package refresh
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"test_task/internal/lib/l/sl"
+	"test_task/internal/metadata"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"test_task/pkg/e"
+	"test_task/pkg/songlink"
+	"time"
+)
+
+type Options struct {
+	Interval  time.Duration
+	MaxAge    time.Duration
+	BatchSize int
+	Timeout   time.Duration
+}
+
+// Refresher re-syncs saved songs with the metadata provider. On every tick it refreshes songs
+// that were not refreshed for MaxAge, and songs with empty text or release date
+// that were not refreshed during the last Interval. A song that failed to refresh is retried
+// not earlier than Interval after the failure.
+type Refresher struct {
+	db   storage.Storage
+	meta metadata.MetadataProvider
+	log  *slog.Logger
+	opts Options
+	wg   sync.WaitGroup
+}
+
+func New(db storage.Storage, meta metadata.MetadataProvider, log *slog.Logger, opts Options) *Refresher {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+
+	return &Refresher{
+		db:   db,
+		meta: meta,
+		log:  log,
+		opts: opts,
+	}
+}
+
+// Start launches the scheduler, a zero Interval disables it. It stops when ctx is canceled, use Wait to wait for it.
+func (r *Refresher) Start(ctx context.Context) {
+	if r.opts.Interval <= 0 {
+		return
+	}
+
+	r.wg.Add(1)
+
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.refreshBatch(ctx)
+			}
+		}
+	}()
+}
+
+func (r *Refresher) Wait() {
+	r.wg.Wait()
+}
+
+func (r *Refresher) refreshBatch(ctx context.Context) {
+	log := r.log.With(slog.String("fn", "refresh.refreshBatch"))
+
+	now := time.Now()
+
+	ids, err := r.db.SongsToRefresh(ctx, now.Add(-r.opts.MaxAge), now.Add(-r.opts.Interval), r.opts.BatchSize)
+	if err != nil {
+		log.Error("failed to get songs to refresh", sl.Err(err))
+
+		return
+	}
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+
+		changes, err := r.RefreshSong(ctx, id, storage.ChangeSourceScheduler)
+		if err != nil {
+			log.Warn("failed to refresh song", slog.Int("songID", id), sl.Err(err))
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err := r.db.MarkRefreshFailed(ctx, id); err != nil {
+				log.Error("failed to mark refresh as failed", slog.Int("songID", id), sl.Err(err))
+			}
+
+			continue
+		}
+
+		if len(changes) > 0 {
+			log.Info("song refreshed", slog.Int("songID", id), slog.Any("changes", changes))
+		}
+	}
+
+	log.Debug("refresh batch done", slog.Int("songs", len(ids)))
+}
+
+// RefreshSong fetches the song info from the metadata provider and applies the fields that differ.
+// Empty values from the provider never overwrite stored ones.
+func (r *Refresher) RefreshSong(ctx context.Context, songID int, source string) ([]models.SongChange, error) {
+	const fn = "refresh.RefreshSong"
+
+	song, err := r.db.GetSong(ctx, songID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(metadata.WithoutCache(ctx), r.opts.Timeout)
+	defer cancel()
+
+	resp, err := r.meta.GetSongInfo(reqCtx, song.GroupName, song.Song)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	changes := diff(song, resp, source)
+
+	if err := r.db.ApplySongChanges(ctx, songID, changes); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return changes, nil
+}
+
+func diff(song *storage.SongInfo, resp *metadata.SongInfo, source string) []models.SongChange {
+	changes := []models.SongChange{}
+
+	change := func(field, oldValue, newValue string) {
+		if newValue == oldValue {
+			return
+		}
+
+		changes = append(changes, models.SongChange{
+			Field:     field,
+			OldValue:  oldValue,
+			NewValue:  newValue,
+			Source:    source,
+			ChangedAt: time.Now(),
+		})
+	}
+
+	add := func(field, oldValue, newValue string) {
+		if newValue != "" {
+			change(field, oldValue, newValue)
+		}
+	}
+
+	if releaseDate, err := time.Parse("02.01.2006", resp.ReleaseDate); err == nil {
+		add("release_date", song.Date.Format("02.01.2006"), releaseDate.Format("02.01.2006"))
+	}
+
+	add("song_text", song.Text, resp.Text)
+
+	// the platform and its id follow the link, the id may become empty
+	if link := songlink.Normalize(resp.Link); link.URL != "" {
+		add("link", song.Link, link.URL)
+		change("platform", song.Platform, link.Platform)
+		change("external_id", song.ExternalID, link.ExternalID)
+	}
+
+	// the track number is taken only together with the album
+	if resp.Album != "" {
+		add("album", song.Album, resp.Album)
+
+		if resp.TrackNumber > 0 {
+			oldTrack := ""
+			if song.TrackNumber != nil {
+				oldTrack = strconv.Itoa(*song.TrackNumber)
+			}
+
+			add("track_number", oldTrack, strconv.Itoa(resp.TrackNumber))
+		}
+	}
+
+	return changes
+}
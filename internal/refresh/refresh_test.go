@@ -0,0 +1,100 @@
+package refresh
+
+import (
+	"reflect"
+	"test_task/internal/metadata"
+	"test_task/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	song := &storage.SongInfo{
+		Song: "Supermassive Black Hole",
+		Date: time.Date(2006, time.July, 16, 0, 0, 0, 0, time.UTC),
+		Text: "old text",
+		Link: "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+
+		Platform:   "youtube",
+		ExternalID: "Xsp3_a-PMTw",
+	}
+
+	tests := []struct {
+		name string
+		resp metadata.SongInfo
+		want [][3]string
+	}{
+		{
+			name: "nothing changed",
+			resp: metadata.SongInfo{
+				ReleaseDate: "16.07.2006",
+				Text:        song.Text,
+				Link:        song.Link,
+			},
+		},
+		{
+			name: "empty values keep the stored ones",
+			resp: metadata.SongInfo{},
+		},
+		{
+			name: "invalid date is ignored",
+			resp: metadata.SongInfo{ReleaseDate: "2006-07-16"},
+		},
+		{
+			name: "date and text changed",
+			resp: metadata.SongInfo{
+				ReleaseDate: "19.06.2006",
+				Text:        "new text",
+			},
+			want: [][3]string{
+				{"release_date", "16.07.2006", "19.06.2006"},
+				{"song_text", song.Text, "new text"},
+			},
+		},
+		{
+			name: "link changed",
+			resp: metadata.SongInfo{Link: "https://example.com/song"},
+			want: [][3]string{
+				{"link", song.Link, "https://example.com/song"},
+				{"platform", "youtube", "other"},
+				{"external_id", "Xsp3_a-PMTw", ""},
+			},
+		},
+		{
+			name: "link canonicalized before comparing",
+			resp: metadata.SongInfo{Link: "https://youtu.be/Xsp3_a-PMTw?si=share"},
+		},
+		{
+			name: "track number without album is ignored",
+			resp: metadata.SongInfo{TrackNumber: 3},
+		},
+		{
+			name: "album and track number",
+			resp: metadata.SongInfo{Album: "Black Holes and Revelations", TrackNumber: 3},
+			want: [][3]string{
+				{"album", "", "Black Holes and Revelations"},
+				{"track_number", "", "3"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := diff(song, &tt.resp, storage.ChangeSourceScheduler)
+
+			var got [][3]string
+
+			for _, change := range changes {
+				if change.Source != storage.ChangeSourceScheduler {
+					t.Errorf("change %s source = %q", change.Field, change.Source)
+				}
+
+				got = append(got, [3]string{change.Field, change.OldValue, change.NewValue})
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
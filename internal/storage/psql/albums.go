@@ -0,0 +1,279 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"strings"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"test_task/pkg/e"
+	"time"
+)
+
+func (s *Storage) CreateAlbum(ctx context.Context, groupID int64, title string, releaseDate time.Time) (*models.Album, error) {
+	const fn = "psql.CreateAlbum"
+
+	q := `
+	INSERT INTO albums (group_id, title, release_date)
+	VALUES ($1, $2, $3)
+	RETURNING id;`
+
+	var albumID int64
+
+	if err := s.db.QueryRowContext(ctx, q, groupID, title, nullDate(releaseDate)).Scan(&albumID); err != nil {
+		return nil, e.Wrap(fn, albumErr(err))
+	}
+
+	album, err := getAlbum(ctx, s.db, albumID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return album, nil
+}
+
+// GetAlbum returns the album with its songs ordered by track number, songs without a number go last.
+func (s *Storage) GetAlbum(ctx context.Context, albumID int64) (*models.Album, error) {
+	const fn = "psql.GetAlbum"
+
+	album, err := getAlbum(ctx, s.db, albumID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return album, nil
+}
+
+// GetAlbums returns the albums of the group without their songs, all albums with groupID 0.
+func (s *Storage) GetAlbums(ctx context.Context, groupID int64) ([]models.Album, error) {
+	const fn = "psql.GetAlbums"
+
+	q := `
+	SELECT a.id, a.group_id, a.title, a.release_date, (SELECT COUNT(*) FROM songs s WHERE s.album_id = a.id)
+	FROM albums a
+	WHERE $1 = 0 OR a.group_id = $1
+	ORDER BY a.group_id, a.release_date NULLS LAST, a.id;`
+
+	rows, err := s.db.QueryContext(ctx, q, groupID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	albums := []models.Album{}
+
+	for rows.Next() {
+		var (
+			album models.Album
+			rd    sql.NullTime
+		)
+
+		if err := rows.Scan(&album.AlbumID, &album.GroupID, &album.Title, &rd, &album.SongsCount); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		album.ReleaseDate = formatDate(rd)
+
+		albums = append(albums, album)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return albums, nil
+}
+
+func (s *Storage) UpdateAlbum(ctx context.Context, albumID int64, update *storage.AlbumUpdate) (*models.Album, error) {
+	const fn = "psql.UpdateAlbum"
+
+	var args []interface{}
+	var sets []string
+	paramIndex := 1
+
+	if update.Title != nil {
+		sets = append(sets, fmt.Sprintf("title = $%d", paramIndex))
+		args = append(args, *update.Title)
+		paramIndex++
+	}
+	if update.ReleaseDate != nil {
+		sets = append(sets, fmt.Sprintf("release_date = $%d", paramIndex))
+		args = append(args, nullDate(*update.ReleaseDate))
+		paramIndex++
+	}
+
+	if len(sets) == 0 {
+		return nil, e.Wrap(fn, storage.ErrNoFieldsUpdate)
+	}
+
+	q := fmt.Sprintf(`UPDATE albums SET %s WHERE id = $%d;`, strings.Join(sets, ", "), paramIndex)
+	args = append(args, albumID)
+
+	res, err := s.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return nil, e.Wrap(fn, albumErr(err))
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, e.Wrap(fn, storage.ErrAlbumNotFound)
+	}
+
+	album, err := getAlbum(ctx, s.db, albumID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return album, nil
+}
+
+// DeleteAlbum deletes the album, its songs are kept without an album and a track number.
+func (s *Storage) DeleteAlbum(ctx context.Context, albumID int64) error {
+	const fn = "psql.DeleteAlbum"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE songs SET album_id = NULL, track_number = NULL WHERE album_id = $1;`, albumID); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM albums WHERE id = $1;`, albumID)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return e.Wrap(fn, storage.ErrAlbumNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	return nil
+}
+
+func getAlbum(ctx context.Context, q querier, albumID int64) (*models.Album, error) {
+	var (
+		album models.Album
+		rd    sql.NullTime
+	)
+
+	err := q.QueryRowContext(ctx, `SELECT id, group_id, title, release_date FROM albums WHERE id = $1;`, albumID).
+		Scan(&album.AlbumID, &album.GroupID, &album.Title, &rd)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrAlbumNotFound
+		}
+
+		return nil, err
+	}
+
+	album.ReleaseDate = formatDate(rd)
+
+	query := `
+	SELECT s.id, s.song, s.release_date, s.song_text, s.link, s.status, s.link_status, s.platform, s.external_id,
+		COALESCE(s.track_number, 0)
+	FROM songs s
+	WHERE s.album_id = $1
+	ORDER BY s.track_number NULLS LAST, s.id;`
+
+	rows, err := q.QueryContext(ctx, query, albumID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	album.Songs = []models.Song{}
+
+	for rows.Next() {
+		var (
+			song models.Song
+			srd  time.Time
+		)
+
+		err := rows.Scan(&song.SongID, &song.SongName, &srd, &song.SongText, &song.Link, &song.Status, &song.LinkStatus,
+			&song.Platform, &song.ExternalID, &song.TrackNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		song.ReleaseDate = srd.Format("02.01.2006")
+		song.AlbumID = album.AlbumID
+		song.AlbumTitle = album.Title
+
+		album.Songs = append(album.Songs, song)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	album.SongsCount = len(album.Songs)
+
+	return &album, nil
+}
+
+// upsertAlbum returns the id of the album of the song group with the title, creating the album if needed.
+func upsertAlbum(ctx context.Context, q querier, songID int, title string) (int64, error) {
+	query := `
+	INSERT INTO albums (group_id, title)
+	SELECT group_id, $2 FROM songs WHERE id = $1
+	ON CONFLICT (group_id, title) DO UPDATE SET title = EXCLUDED.title
+	RETURNING id;`
+
+	var albumID int64
+
+	if err := q.QueryRowContext(ctx, query, songID, title).Scan(&albumID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, storage.ErrSongNotFound
+		}
+
+		return 0, err
+	}
+
+	return albumID, nil
+}
+
+func albumErr(err error) error {
+	var pqErr *pq.Error
+
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505":
+			return storage.ErrAlbumExists
+		case "23503":
+			return storage.ErrGroupNotFound
+		}
+	}
+
+	return err
+}
+
+func nullDate(date time.Time) sql.NullTime {
+	return sql.NullTime{Time: date, Valid: !date.IsZero()}
+}
+
+func formatDate(date sql.NullTime) string {
+	if !date.Valid {
+		return ""
+	}
+
+	return date.Time.Format("02.01.2006")
+}
@@ -0,0 +1,112 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"test_task/pkg/e"
+)
+
+func (s *Storage) CreateAPIKey(ctx context.Context, name, role, prefix, keyHash string) (*models.APIKey, error) {
+	const fn = "psql.CreateAPIKey"
+
+	q := `
+	INSERT INTO api_keys (name, role, prefix, key_hash)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, name, role, prefix, created_at, last_used_at, revoked_at;`
+
+	key, err := scanAPIKey(s.db.QueryRowContext(ctx, q, name, role, prefix, keyHash))
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return key, nil
+}
+
+func (s *Storage) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	const fn = "psql.ListAPIKeys"
+
+	q := `
+	SELECT id, name, role, prefix, created_at, last_used_at, revoked_at
+	FROM api_keys
+	ORDER BY id;`
+
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return keys, nil
+}
+
+func (s *Storage) RevokeAPIKey(ctx context.Context, keyID int64) error {
+	const fn = "psql.RevokeAPIKey"
+
+	q := `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL;`
+
+	res, err := s.db.ExecContext(ctx, q, keyID)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return e.Wrap(fn, storage.ErrAPIKeyNotFound)
+	}
+
+	return nil
+}
+
+// AuthenticateAPIKey returns the key that is not revoked and records its use.
+func (s *Storage) AuthenticateAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	const fn = "psql.AuthenticateAPIKey"
+
+	q := `
+	UPDATE api_keys SET last_used_at = NOW()
+	WHERE key_hash = $1 AND revoked_at IS NULL
+	RETURNING id, name, role, prefix, created_at, last_used_at, revoked_at;`
+
+	key, err := scanAPIKey(s.db.QueryRowContext(ctx, q, keyHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.Wrap(fn, storage.ErrAPIKeyNotFound)
+		}
+
+		return nil, e.Wrap(fn, err)
+	}
+
+	return key, nil
+}
+
+func scanAPIKey(row interface{ Scan(dest ...any) error }) (*models.APIKey, error) {
+	var key models.APIKey
+
+	err := row.Scan(&key.KeyID, &key.Name, &key.Role, &key.Prefix, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
@@ -0,0 +1,369 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"strings"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"test_task/pkg/e"
+)
+
+func (s *Storage) CreateArtist(ctx context.Context, name string) (*models.Artist, error) {
+	const fn = "psql.CreateArtist"
+
+	q := `INSERT INTO artists (name) VALUES ($1) RETURNING id, name, created_at;`
+
+	var artist models.Artist
+
+	if err := s.db.QueryRowContext(ctx, q, name).Scan(&artist.ArtistID, &artist.Name, &artist.CreatedAt); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return &artist, nil
+}
+
+// GetArtist returns the artist with its groups and song credits.
+func (s *Storage) GetArtist(ctx context.Context, artistID int64) (*models.Artist, error) {
+	const fn = "psql.GetArtist"
+
+	var artist models.Artist
+
+	err := s.db.QueryRowContext(ctx, `SELECT id, name, created_at FROM artists WHERE id = $1;`, artistID).
+		Scan(&artist.ArtistID, &artist.Name, &artist.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.Wrap(fn, storage.ErrArtistNotFound)
+		}
+
+		return nil, e.Wrap(fn, err)
+	}
+
+	q := `
+	SELECT g.id, g.group_name, COALESCE(gm.joined_year, 0), COALESCE(gm.left_year, 0)
+	FROM group_members gm
+	JOIN groups g ON g.id = gm.group_id
+	WHERE gm.artist_id = $1
+	ORDER BY gm.joined_year NULLS LAST, g.id;`
+
+	rows, err := s.db.QueryContext(ctx, q, artistID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	artist.Groups = []models.ArtistGroup{}
+
+	for rows.Next() {
+		var group models.ArtistGroup
+
+		if err := rows.Scan(&group.GroupID, &group.GroupName, &group.JoinedYear, &group.LeftYear); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		artist.Groups = append(artist.Groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	q = `
+	SELECT s.id, s.song, g.group_name, sc.role
+	FROM song_credits sc
+	JOIN songs s ON s.id = sc.song_id
+	JOIN groups g ON g.id = s.group_id
+	WHERE sc.artist_id = $1
+	ORDER BY s.id, sc.role;`
+
+	creditRows, err := s.db.QueryContext(ctx, q, artistID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer creditRows.Close()
+
+	artist.Credits = []models.ArtistCredit{}
+
+	for creditRows.Next() {
+		var credit models.ArtistCredit
+
+		if err := creditRows.Scan(&credit.SongID, &credit.SongName, &credit.GroupName, &credit.Role); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		artist.Credits = append(artist.Credits, credit)
+	}
+
+	if err := creditRows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return &artist, nil
+}
+
+// SearchArtists returns the artists whose name contains name case-insensitively, all artists with an empty name.
+func (s *Storage) SearchArtists(ctx context.Context, name string, offset, limit int) ([]models.Artist, error) {
+	const fn = "psql.SearchArtists"
+
+	q := `
+	SELECT id, name, created_at
+	FROM artists
+	WHERE name ILIKE '%' || $1 || '%'
+	ORDER BY LOWER(name), id
+	OFFSET $2`
+
+	args := []interface{}{escapeLike(name), offset}
+
+	if limit > 0 {
+		q += " LIMIT $3"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	artists := []models.Artist{}
+
+	for rows.Next() {
+		var artist models.Artist
+
+		if err := rows.Scan(&artist.ArtistID, &artist.Name, &artist.CreatedAt); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		artists = append(artists, artist)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return artists, nil
+}
+
+func (s *Storage) UpdateArtist(ctx context.Context, artistID int64, name string) (*models.Artist, error) {
+	const fn = "psql.UpdateArtist"
+
+	q := `UPDATE artists SET name = $1 WHERE id = $2 RETURNING id, name, created_at;`
+
+	var artist models.Artist
+
+	if err := s.db.QueryRowContext(ctx, q, name, artistID).Scan(&artist.ArtistID, &artist.Name, &artist.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.Wrap(fn, storage.ErrArtistNotFound)
+		}
+
+		return nil, e.Wrap(fn, err)
+	}
+
+	return &artist, nil
+}
+
+// DeleteArtist deletes the artist with its memberships and credits.
+func (s *Storage) DeleteArtist(ctx context.Context, artistID int64) error {
+	const fn = "psql.DeleteArtist"
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM artists WHERE id = $1;`, artistID)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return e.Wrap(fn, storage.ErrArtistNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetGroupMembers(ctx context.Context, groupID int64) ([]models.GroupMember, error) {
+	const fn = "psql.GetGroupMembers"
+
+	var exists bool
+
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM groups WHERE id = $1);`, groupID).Scan(&exists); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if !exists {
+		return nil, e.Wrap(fn, storage.ErrGroupNotFound)
+	}
+
+	q := `
+	SELECT a.id, a.name, COALESCE(gm.joined_year, 0), COALESCE(gm.left_year, 0)
+	FROM group_members gm
+	JOIN artists a ON a.id = gm.artist_id
+	WHERE gm.group_id = $1
+	ORDER BY gm.joined_year NULLS LAST, a.id;`
+
+	rows, err := s.db.QueryContext(ctx, q, groupID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	members := []models.GroupMember{}
+
+	for rows.Next() {
+		var member models.GroupMember
+
+		if err := rows.Scan(&member.ArtistID, &member.Name, &member.JoinedYear, &member.LeftYear); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return members, nil
+}
+
+// SetGroupMember adds the artist to the group or updates the membership years, 0 is an unknown year.
+func (s *Storage) SetGroupMember(ctx context.Context, groupID, artistID int64, joinedYear, leftYear int) error {
+	const fn = "psql.SetGroupMember"
+
+	q := `
+	INSERT INTO group_members (group_id, artist_id, joined_year, left_year)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (group_id, artist_id) DO UPDATE SET joined_year = EXCLUDED.joined_year, left_year = EXCLUDED.left_year;`
+
+	if _, err := s.db.ExecContext(ctx, q, groupID, artistID, nullYear(joinedYear), nullYear(leftYear)); err != nil {
+		return e.Wrap(fn, artistForeignKeyErr(err))
+	}
+
+	return nil
+}
+
+func (s *Storage) RemoveGroupMember(ctx context.Context, groupID, artistID int64) error {
+	const fn = "psql.RemoveGroupMember"
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM group_members WHERE group_id = $1 AND artist_id = $2;`, groupID, artistID)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return e.Wrap(fn, storage.ErrMemberNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetSongCredits(ctx context.Context, songID int) ([]models.SongCredit, error) {
+	const fn = "psql.GetSongCredits"
+
+	q := `
+	SELECT a.id, a.name, sc.role
+	FROM song_credits sc
+	JOIN artists a ON a.id = sc.artist_id
+	WHERE sc.song_id = $1
+	ORDER BY sc.role, a.id;`
+
+	rows, err := s.db.QueryContext(ctx, q, songID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	credits := []models.SongCredit{}
+
+	for rows.Next() {
+		var credit models.SongCredit
+
+		if err := rows.Scan(&credit.ArtistID, &credit.Name, &credit.Role); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		credits = append(credits, credit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return credits, nil
+}
+
+// AddSongCredit credits the artist on the song with the role, adding the same credit twice is not an error.
+func (s *Storage) AddSongCredit(ctx context.Context, songID int, artistID int64, role string) error {
+	const fn = "psql.AddSongCredit"
+
+	q := `
+	INSERT INTO song_credits (song_id, artist_id, role)
+	VALUES ($1, $2, $3)
+	ON CONFLICT DO NOTHING;`
+
+	if _, err := s.db.ExecContext(ctx, q, songID, artistID, role); err != nil {
+		return e.Wrap(fn, artistForeignKeyErr(err))
+	}
+
+	return nil
+}
+
+func (s *Storage) RemoveSongCredit(ctx context.Context, songID int, artistID int64, role string) error {
+	const fn = "psql.RemoveSongCredit"
+
+	q := `DELETE FROM song_credits WHERE song_id = $1 AND artist_id = $2 AND role = $3;`
+
+	res, err := s.db.ExecContext(ctx, q, songID, artistID, role)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return e.Wrap(fn, storage.ErrCreditNotFound)
+	}
+
+	return nil
+}
+
+// artistForeignKeyErr turns a foreign key violation into the not found error of the referenced row.
+func artistForeignKeyErr(err error) error {
+	var pqErr *pq.Error
+
+	if !errors.As(err, &pqErr) || pqErr.Code != "23503" {
+		return err
+	}
+
+	switch {
+	case strings.HasSuffix(pqErr.Constraint, "artist_id_fkey"):
+		return storage.ErrArtistNotFound
+	case strings.HasSuffix(pqErr.Constraint, "group_id_fkey"):
+		return storage.ErrGroupNotFound
+	case strings.HasSuffix(pqErr.Constraint, "song_id_fkey"):
+		return storage.ErrSongNotFound
+	}
+
+	return err
+}
+
+func nullYear(year int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(year), Valid: year != 0}
+}
+
+// escapeLike escapes the LIKE wildcards so that the value is matched literally.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
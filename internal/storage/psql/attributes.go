@@ -0,0 +1,247 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"sort"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"test_task/pkg/e"
+)
+
+func (s *Storage) GetAttributeDefinitions(ctx context.Context) ([]models.AttributeDefinition, error) {
+	const fn = "psql.GetAttributeDefinitions"
+
+	rows, err := s.db.QueryContext(ctx, `SELECT name, type, description, created_at FROM attribute_definitions ORDER BY name;`)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	definitions := []models.AttributeDefinition{}
+
+	for rows.Next() {
+		var definition models.AttributeDefinition
+
+		if err := rows.Scan(&definition.Name, &definition.Type, &definition.Description, &definition.CreatedAt); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		definitions = append(definitions, definition)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return definitions, nil
+}
+
+func (s *Storage) CreateAttributeDefinition(ctx context.Context, definition *models.AttributeDefinition) (*models.AttributeDefinition, error) {
+	const fn = "psql.CreateAttributeDefinition"
+
+	q := `
+	INSERT INTO attribute_definitions (name, type, description)
+	VALUES ($1, $2, $3)
+	RETURNING name, type, description, created_at;`
+
+	var created models.AttributeDefinition
+
+	err := s.db.QueryRowContext(ctx, q, definition.Name, definition.Type, definition.Description).
+		Scan(&created.Name, &created.Type, &created.Description, &created.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, e.Wrap(fn, storage.ErrAttributeExists)
+		}
+
+		return nil, e.Wrap(fn, err)
+	}
+
+	return &created, nil
+}
+
+// DeleteAttributeDefinition deletes the attribute and removes its values from all songs and groups.
+func (s *Storage) DeleteAttributeDefinition(ctx context.Context, name string) error {
+	const fn = "psql.DeleteAttributeDefinition"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM attribute_definitions WHERE name = $1;`, name)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return e.Wrap(fn, storage.ErrAttributeNotFound)
+	}
+
+	for _, table := range []string{"songs", "groups"} {
+		q := `UPDATE ` + table + ` SET attributes = attributes - $1::TEXT WHERE attributes ? $1;`
+
+		if _, err := tx.ExecContext(ctx, q, name); err != nil {
+			return e.Wrap(fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetSongAttributes(ctx context.Context, songID int) (map[string]interface{}, error) {
+	const fn = "psql.GetSongAttributes"
+
+	attributes, err := s.getAttributes(ctx, "songs", songID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.Wrap(fn, storage.ErrSongNotFound)
+		}
+
+		return nil, e.Wrap(fn, err)
+	}
+
+	return attributes, nil
+}
+
+// UpdateSongAttributes sets and removes the attributes of the song and returns all its attributes.
+func (s *Storage) UpdateSongAttributes(ctx context.Context, songID int, set map[string]interface{}, remove []string) (map[string]interface{}, error) {
+	const fn = "psql.UpdateSongAttributes"
+
+	attributes, err := s.updateAttributes(ctx, "songs", songID, set, remove)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.Wrap(fn, storage.ErrSongNotFound)
+		}
+
+		return nil, e.Wrap(fn, err)
+	}
+
+	return attributes, nil
+}
+
+func (s *Storage) GetGroupAttributes(ctx context.Context, groupID int64) (map[string]interface{}, error) {
+	const fn = "psql.GetGroupAttributes"
+
+	attributes, err := s.getAttributes(ctx, "groups", groupID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.Wrap(fn, storage.ErrGroupNotFound)
+		}
+
+		return nil, e.Wrap(fn, err)
+	}
+
+	return attributes, nil
+}
+
+// UpdateGroupAttributes sets and removes the attributes of the group and returns all its attributes.
+func (s *Storage) UpdateGroupAttributes(ctx context.Context, groupID int64, set map[string]interface{}, remove []string) (map[string]interface{}, error) {
+	const fn = "psql.UpdateGroupAttributes"
+
+	attributes, err := s.updateAttributes(ctx, "groups", groupID, set, remove)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.Wrap(fn, storage.ErrGroupNotFound)
+		}
+
+		return nil, e.Wrap(fn, err)
+	}
+
+	return attributes, nil
+}
+
+func (s *Storage) getAttributes(ctx context.Context, table string, id interface{}) (map[string]interface{}, error) {
+	var raw []byte
+
+	if err := s.db.QueryRowContext(ctx, `SELECT attributes FROM `+table+` WHERE id = $1;`, id).Scan(&raw); err != nil {
+		return nil, err
+	}
+
+	return decodeAttributes(raw)
+}
+
+func (s *Storage) updateAttributes(ctx context.Context, table string, id interface{}, set map[string]interface{}, remove []string) (map[string]interface{}, error) {
+	if set == nil {
+		set = map[string]interface{}{}
+	}
+
+	encoded, err := json.Marshal(set)
+	if err != nil {
+		return nil, err
+	}
+
+	q := `
+	UPDATE ` + table + `
+	SET attributes = (attributes - $1::TEXT[]) || $2::JSONB
+	WHERE id = $3
+	RETURNING attributes;`
+
+	var raw []byte
+
+	if err := s.db.QueryRowContext(ctx, q, pq.Array(remove), string(encoded), id).Scan(&raw); err != nil {
+		return nil, err
+	}
+
+	return decodeAttributes(raw)
+}
+
+// attributeConditions returns the library conditions on the attributes, the attribute of the song
+// overrides the same attribute of its group.
+func attributeConditions(attributes map[string]interface{}, args *[]interface{}, paramIndex *int) ([]string, error) {
+	names := make([]string, 0, len(attributes))
+
+	for name := range attributes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var sets []string
+
+	for _, name := range names {
+		value, err := json.Marshal(map[string]interface{}{name: attributes[name]})
+		if err != nil {
+			return nil, err
+		}
+
+		sets = append(sets, fmt.Sprintf(
+			"(s.attributes @> $%[1]d::JSONB OR (NOT s.attributes ? $%[2]d AND g.attributes @> $%[1]d::JSONB))",
+			*paramIndex, *paramIndex+1,
+		))
+		*args = append(*args, string(value), name)
+		*paramIndex += 2
+	}
+
+	return sets, nil
+}
+
+func decodeAttributes(raw []byte) (map[string]interface{}, error) {
+	attributes := map[string]interface{}{}
+
+	if len(raw) == 0 {
+		return attributes, nil
+	}
+
+	if err := json.Unmarshal(raw, &attributes); err != nil {
+		return nil, err
+	}
+
+	return attributes, nil
+}
@@ -0,0 +1,56 @@
+package psql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAttributeConditions(t *testing.T) {
+	args := []interface{}{"existing"}
+	paramIndex := 2
+
+	sets, err := attributeConditions(map[string]interface{}{
+		"mood": "calm",
+		"live": true,
+		"rank": float64(3),
+	}, &args, &paramIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the conditions are sorted by the attribute name, so the query is stable
+	wantSets := []string{
+		"(s.attributes @> $2::JSONB OR (NOT s.attributes ? $3 AND g.attributes @> $2::JSONB))",
+		"(s.attributes @> $4::JSONB OR (NOT s.attributes ? $5 AND g.attributes @> $4::JSONB))",
+		"(s.attributes @> $6::JSONB OR (NOT s.attributes ? $7 AND g.attributes @> $6::JSONB))",
+	}
+
+	wantArgs := []interface{}{
+		"existing",
+		`{"live":true}`, "live",
+		`{"mood":"calm"}`, "mood",
+		`{"rank":3}`, "rank",
+	}
+
+	if !reflect.DeepEqual(sets, wantSets) {
+		t.Errorf("conditions = %q, want %q", sets, wantSets)
+	}
+
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %q, want %q", args, wantArgs)
+	}
+
+	if paramIndex != 8 {
+		t.Errorf("paramIndex = %d, want 8", paramIndex)
+	}
+}
+
+func TestAttributeConditionsEmpty(t *testing.T) {
+	var args []interface{}
+	paramIndex := 1
+
+	sets, err := attributeConditions(nil, &args, &paramIndex)
+	if err != nil || len(sets) != 0 || len(args) != 0 || paramIndex != 1 {
+		t.Errorf("attributeConditions(nil) = %q, %v, args %q, paramIndex %d", sets, err, args, paramIndex)
+	}
+}
@@ -0,0 +1,86 @@
+package psql
+
+import (
+	"context"
+	"test_task/internal/storage"
+	"test_task/pkg/e"
+	"time"
+)
+
+// CreateIdempotencyKey drops expired keys and stores the key as in progress for lease.
+// A key still in progress after its lease is taken over as if it was not stored.
+// If the key is already stored, the existing record is returned and the bool is false.
+func (s *Storage) CreateIdempotencyKey(ctx context.Context, key, requestHash string, expiredBefore time.Time, lease time.Duration) (*storage.IdempotencyRecord, bool, error) {
+	const fn = "psql.CreateIdempotencyKey"
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < $1;`, expiredBefore); err != nil {
+		return nil, false, e.Wrap(fn, err)
+	}
+
+	q := `
+	INSERT INTO idempotency_keys (key, request_hash, locked_until)
+	VALUES ($1, $2, NOW() + $3 * INTERVAL '1 second')
+	ON CONFLICT (key) DO UPDATE
+	SET request_hash = EXCLUDED.request_hash, locked_until = EXCLUDED.locked_until, created_at = NOW()
+	WHERE idempotency_keys.status_code = 0 AND idempotency_keys.locked_until < NOW();`
+
+	res, err := s.db.ExecContext(ctx, q, key, requestHash, lease.Seconds())
+	if err != nil {
+		return nil, false, e.Wrap(fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, false, e.Wrap(fn, err)
+	}
+
+	if rowsAffected == 1 {
+		return nil, true, nil
+	}
+
+	q = `
+	SELECT key, request_hash, status_code, content_type, COALESCE(body, ''), created_at
+	FROM idempotency_keys
+	WHERE key = $1;`
+
+	var record storage.IdempotencyRecord
+
+	err = s.db.QueryRowContext(ctx, q, key).Scan(
+		&record.Key,
+		&record.RequestHash,
+		&record.StatusCode,
+		&record.ContentType,
+		&record.Body,
+		&record.CreatedAt,
+	)
+	if err != nil {
+		return nil, false, e.Wrap(fn, err)
+	}
+
+	return &record, false, nil
+}
+
+func (s *Storage) SaveIdempotentResponse(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	const fn = "psql.SaveIdempotentResponse"
+
+	q := `
+	UPDATE idempotency_keys
+	SET status_code = $1, content_type = $2, body = $3
+	WHERE key = $4;`
+
+	if _, err := s.db.ExecContext(ctx, q, statusCode, contentType, body, key); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	const fn = "psql.DeleteIdempotencyKey"
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1;`, key); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	return nil
+}
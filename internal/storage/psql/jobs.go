@@ -0,0 +1,155 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"test_task/pkg/e"
+	"time"
+)
+
+func (s *Storage) SaveJob(ctx context.Context, songID int64) (int64, error) {
+	const fn = "psql.SaveJob"
+
+	q := `
+	INSERT INTO jobs (song_id, status)
+	VALUES ($1, $2)
+	RETURNING id;`
+
+	var jobID int64
+
+	if err := s.db.QueryRowContext(ctx, q, songID, storage.JobStatusPending).Scan(&jobID); err != nil {
+		return 0, e.Wrap(fn, err)
+	}
+
+	return jobID, nil
+}
+
+func (s *Storage) GetJob(ctx context.Context, jobID int64) (*models.Job, error) {
+	const fn = "psql.GetJob"
+
+	q := `
+	SELECT id, song_id, status, attempts, error, created_at, updated_at
+	FROM jobs
+	WHERE id = $1;`
+
+	job, err := scanJob(s.db.QueryRowContext(ctx, q, jobID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.Wrap(fn, storage.ErrJobNotFound)
+		}
+
+		return nil, e.Wrap(fn, err)
+	}
+
+	return job, nil
+}
+
+// ClaimJob moves a pending job into the running state and counts the attempt.
+// It reports false if the job is not pending, e.g. it was already picked up by another worker.
+func (s *Storage) ClaimJob(ctx context.Context, jobID int64) (*models.Job, bool, error) {
+	const fn = "psql.ClaimJob"
+
+	q := `
+	UPDATE jobs
+	SET status = $1, attempts = attempts + 1, updated_at = NOW()
+	WHERE id = $2 AND status = $3
+	RETURNING id, song_id, status, attempts, error, created_at, updated_at;`
+
+	job, err := scanJob(s.db.QueryRowContext(ctx, q, storage.JobStatusRunning, jobID, storage.JobStatusPending))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+
+		return nil, false, e.Wrap(fn, err)
+	}
+
+	return job, true, nil
+}
+
+func (s *Storage) UpdateJob(ctx context.Context, jobID int64, status, errMsg string) error {
+	const fn = "psql.UpdateJob"
+
+	q := `UPDATE jobs SET status = $1, error = $2, updated_at = NOW() WHERE id = $3;`
+
+	res, err := s.db.ExecContext(ctx, q, status, errMsg, jobID)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return e.Wrap(fn, storage.ErrJobNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) PendingJobs(ctx context.Context, updatedBefore time.Time) ([]int64, error) {
+	const fn = "psql.PendingJobs"
+
+	q := `SELECT id FROM jobs WHERE status = $1 AND updated_at < $2 ORDER BY id;`
+
+	rows, err := s.db.QueryContext(ctx, q, storage.JobStatusPending, updatedBefore)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+
+	for rows.Next() {
+		var id int64
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return ids, nil
+}
+
+// ResetRunningJobs returns jobs left in the running state by a previous process back to pending.
+func (s *Storage) ResetRunningJobs(ctx context.Context) error {
+	const fn = "psql.ResetRunningJobs"
+
+	q := `UPDATE jobs SET status = $1, updated_at = NOW() WHERE status = $2;`
+
+	if _, err := s.db.ExecContext(ctx, q, storage.JobStatusPending, storage.JobStatusRunning); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	return nil
+}
+
+func scanJob(row *sql.Row) (*models.Job, error) {
+	var job models.Job
+
+	err := row.Scan(
+		&job.JobID,
+		&job.SongID,
+		&job.Status,
+		&job.Attempts,
+		&job.Error,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
@@ -0,0 +1,125 @@
+package psql
+
+import (
+	"context"
+	"github.com/lib/pq"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"test_task/pkg/e"
+	"time"
+)
+
+// LinksToCheck returns non-empty links that were never checked or were checked before checkedBefore,
+// the ones never checked go first. The primary link is checked in songs, the other song links in song_links.
+func (s *Storage) LinksToCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]storage.LinkToCheck, error) {
+	const fn = "psql.LinksToCheck"
+
+	q := `
+	SELECT song_id, link_id, link FROM (
+		SELECT id AS song_id, 0 AS link_id, link, link_checked_at FROM songs
+		WHERE link <> '' AND link <> 'NULL'
+		UNION ALL
+		SELECT song_id, id, url, link_checked_at FROM song_links
+		WHERE NOT is_primary
+	) l
+	WHERE link_checked_at IS NULL OR link_checked_at < $1
+	ORDER BY link_checked_at NULLS FIRST, song_id, link_id
+	LIMIT $2;`
+
+	rows, err := s.db.QueryContext(ctx, q, checkedBefore, limit)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	var links []storage.LinkToCheck
+
+	for rows.Next() {
+		var link storage.LinkToCheck
+
+		if err := rows.Scan(&link.SongID, &link.LinkID, &link.Link); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return links, nil
+}
+
+// SetLinkStatus records the result of a link check, linkID 0 is the primary link of the song.
+// The status is dropped if the link was changed while it was checked.
+func (s *Storage) SetLinkStatus(ctx context.Context, songID int, linkID int64, link, status string) error {
+	const fn = "psql.SetLinkStatus"
+
+	q := `UPDATE songs SET link_status = $1, link_checked_at = NOW() WHERE id = $2 AND link = $3;`
+	args := []interface{}{status, songID, link}
+
+	if linkID != 0 {
+		q = `UPDATE song_links SET link_status = $1, link_checked_at = NOW() WHERE song_id = $2 AND url = $3 AND id = $4;`
+		args = append(args, linkID)
+	}
+
+	if _, err := s.db.ExecContext(ctx, q, args...); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	return nil
+}
+
+// GetBrokenLinks returns the primary and the other song links with one of the statuses, the last checked first.
+func (s *Storage) GetBrokenLinks(ctx context.Context, statuses []string, offset, limit int) ([]models.BrokenLink, error) {
+	const fn = "psql.GetBrokenLinks"
+
+	q := `
+	SELECT s.id, s.song, g.id, g.group_name, COALESCE(p.id, 0), TRUE, s.link, s.link_status, s.link_checked_at
+	FROM songs s
+	JOIN groups g ON g.id = s.group_id
+	LEFT JOIN song_links p ON p.song_id = s.id AND p.is_primary
+	WHERE s.link_status = ANY($1)
+	UNION ALL
+	SELECT s.id, s.song, g.id, g.group_name, l.id, FALSE, l.url, l.link_status, l.link_checked_at
+	FROM song_links l
+	JOIN songs s ON s.id = l.song_id
+	JOIN groups g ON g.id = s.group_id
+	WHERE NOT l.is_primary AND l.link_status = ANY($1)
+	ORDER BY 9 DESC, 1, 5
+	OFFSET $2`
+
+	args := []interface{}{pq.Array(statuses), offset}
+
+	if limit > 0 {
+		q += " LIMIT $3"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	links := []models.BrokenLink{}
+
+	for rows.Next() {
+		var link models.BrokenLink
+
+		err := rows.Scan(&link.SongID, &link.SongName, &link.GroupID, &link.GroupName,
+			&link.LinkID, &link.Primary, &link.Link, &link.LinkStatus, &link.LinkCheckedAt)
+		if err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return links, nil
+}
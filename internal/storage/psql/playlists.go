@@ -0,0 +1,465 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"strings"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"test_task/pkg/e"
+)
+
+const playlistColumns = `p.id, p.user_id, p.name, p.description, p.created_at, p.updated_at`
+
+func (s *Storage) CreatePlaylist(ctx context.Context, userID int64, name, description string) (*models.Playlist, error) {
+	const fn = "psql.CreatePlaylist"
+
+	q := `
+	INSERT INTO playlists AS p (user_id, name, description)
+	VALUES ($1, $2, $3)
+	RETURNING ` + playlistColumns + `;`
+
+	playlist, err := scanPlaylist(s.db.QueryRowContext(ctx, q, userID, name, description))
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	playlist.Songs = []models.PlaylistSong{}
+
+	return playlist, nil
+}
+
+// GetPlaylist returns the playlist with its songs ordered by position.
+func (s *Storage) GetPlaylist(ctx context.Context, playlistID int64) (*models.Playlist, error) {
+	const fn = "psql.GetPlaylist"
+
+	playlist, err := getPlaylist(ctx, s.db, playlistID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return playlist, nil
+}
+
+// GetUserPlaylists returns the playlists of the user without their songs.
+func (s *Storage) GetUserPlaylists(ctx context.Context, userID int64) ([]models.Playlist, error) {
+	const fn = "psql.GetUserPlaylists"
+
+	q := `
+	SELECT ` + playlistColumns + `, (SELECT COUNT(*) FROM playlist_songs ps WHERE ps.playlist_id = p.id)
+	FROM playlists p
+	WHERE p.user_id = $1
+	ORDER BY p.id;`
+
+	rows, err := s.db.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	playlists := []models.Playlist{}
+
+	for rows.Next() {
+		var p models.Playlist
+
+		if err := rows.Scan(&p.PlaylistID, &p.OwnerID, &p.Name, &p.Description, &p.CreatedAt, &p.UpdatedAt, &p.SongsCount); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		playlists = append(playlists, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return playlists, nil
+}
+
+func (s *Storage) UpdatePlaylist(ctx context.Context, playlistID int64, update *storage.PlaylistUpdate) (*models.Playlist, error) {
+	const fn = "psql.UpdatePlaylist"
+
+	var args []interface{}
+	var sets []string
+	paramIndex := 1
+
+	if update.Name != nil {
+		sets = append(sets, fmt.Sprintf("name = $%d", paramIndex))
+		args = append(args, *update.Name)
+		paramIndex++
+	}
+	if update.Description != nil {
+		sets = append(sets, fmt.Sprintf("description = $%d", paramIndex))
+		args = append(args, *update.Description)
+		paramIndex++
+	}
+
+	if len(sets) == 0 {
+		return nil, e.Wrap(fn, storage.ErrNoFieldsUpdate)
+	}
+
+	q := fmt.Sprintf(`UPDATE playlists SET %s, updated_at = NOW() WHERE id = $%d;`, strings.Join(sets, ", "), paramIndex)
+	args = append(args, playlistID)
+
+	res, err := s.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, e.Wrap(fn, storage.ErrPlaylistNotFound)
+	}
+
+	playlist, err := getPlaylist(ctx, s.db, playlistID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return playlist, nil
+}
+
+func (s *Storage) DeletePlaylist(ctx context.Context, playlistID int64) error {
+	const fn = "psql.DeletePlaylist"
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM playlists WHERE id = $1;`, playlistID)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return e.Wrap(fn, storage.ErrPlaylistNotFound)
+	}
+
+	return nil
+}
+
+// DuplicatePlaylist copies the playlist with its songs to the user,
+// the copy is named after the original when name is empty.
+func (s *Storage) DuplicatePlaylist(ctx context.Context, playlistID, userID int64, name string) (*models.Playlist, error) {
+	const fn = "psql.DuplicatePlaylist"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer tx.Rollback()
+
+	q := `
+	INSERT INTO playlists (user_id, name, description)
+	SELECT $1, COALESCE(NULLIF($2, ''), name || ' (copy)'), description
+	FROM playlists
+	WHERE id = $3
+	RETURNING id;`
+
+	var copyID int64
+
+	if err := tx.QueryRowContext(ctx, q, userID, name, playlistID).Scan(&copyID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.Wrap(fn, storage.ErrPlaylistNotFound)
+		}
+
+		return nil, e.Wrap(fn, err)
+	}
+
+	q = `
+	INSERT INTO playlist_songs (playlist_id, song_id, position)
+	SELECT $1, song_id, position
+	FROM playlist_songs
+	WHERE playlist_id = $2;`
+
+	if _, err := tx.ExecContext(ctx, q, copyID, playlistID); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	playlist, err := getPlaylist(ctx, tx, copyID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return playlist, nil
+}
+
+// AddPlaylistSong inserts the song at the position moving the next songs down,
+// position 0 or past the end appends it.
+func (s *Storage) AddPlaylistSong(ctx context.Context, playlistID int64, songID int, position int) (*models.Playlist, error) {
+	const fn = "psql.AddPlaylistSong"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer tx.Rollback()
+
+	count, err := touchPlaylist(ctx, tx, playlistID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if position <= 0 || position > count {
+		position = count + 1
+	}
+
+	shift := `UPDATE playlist_songs SET position = position + 1 WHERE playlist_id = $1 AND position >= $2;`
+
+	if _, err := tx.ExecContext(ctx, shift, playlistID, position); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	insert := `INSERT INTO playlist_songs (playlist_id, song_id, position) VALUES ($1, $2, $3);`
+
+	if _, err := tx.ExecContext(ctx, insert, playlistID, songID, position); err != nil {
+		var pqErr *pq.Error
+
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "playlist_songs_pkey" {
+			return nil, e.Wrap(fn, storage.ErrPlaylistSongExists)
+		}
+
+		return nil, e.Wrap(fn, songForeignKeyErr(err))
+	}
+
+	playlist, err := getPlaylist(ctx, tx, playlistID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return playlist, nil
+}
+
+func (s *Storage) RemovePlaylistSong(ctx context.Context, playlistID int64, songID int) (*models.Playlist, error) {
+	const fn = "psql.RemovePlaylistSong"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := touchPlaylist(ctx, tx, playlistID); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM playlist_songs WHERE playlist_id = $1 AND song_id = $2;`, playlistID, songID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, e.Wrap(fn, storage.ErrPlaylistSongNotFound)
+	}
+
+	if err := compactPlaylists(ctx, tx, []int64{playlistID}); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	playlist, err := getPlaylist(ctx, tx, playlistID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return playlist, nil
+}
+
+// ReorderPlaylist sets the positions of the songs to their order in songIDs,
+// which has to list every song of the playlist exactly once.
+func (s *Storage) ReorderPlaylist(ctx context.Context, playlistID int64, songIDs []int64) (*models.Playlist, error) {
+	const fn = "psql.ReorderPlaylist"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer tx.Rollback()
+
+	count, err := touchPlaylist(ctx, tx, playlistID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if len(songIDs) != count {
+		return nil, e.Wrap(fn, storage.ErrInvalidOrder)
+	}
+
+	q := `
+	UPDATE playlist_songs ps
+	SET position = o.position
+	FROM unnest($2::BIGINT[]) WITH ORDINALITY AS o(song_id, position)
+	WHERE ps.playlist_id = $1 AND ps.song_id = o.song_id;`
+
+	res, err := tx.ExecContext(ctx, q, playlistID, pq.Array(songIDs))
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	// Every song of the playlist is updated once only when songIDs has no unknown ids and no duplicates.
+	if rowsAffected != int64(count) {
+		return nil, e.Wrap(fn, storage.ErrInvalidOrder)
+	}
+
+	playlist, err := getPlaylist(ctx, tx, playlistID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return playlist, nil
+}
+
+func scanPlaylist(row *sql.Row) (*models.Playlist, error) {
+	var p models.Playlist
+
+	if err := row.Scan(&p.PlaylistID, &p.OwnerID, &p.Name, &p.Description, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrPlaylistNotFound
+		}
+
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func getPlaylist(ctx context.Context, q querier, playlistID int64) (*models.Playlist, error) {
+	playlist, err := scanPlaylist(q.QueryRowContext(ctx, `SELECT `+playlistColumns+` FROM playlists p WHERE p.id = $1;`, playlistID))
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT ps.position, s.id, s.song, g.id, g.group_name, s.link, ps.added_at
+	FROM playlist_songs ps
+	JOIN songs s ON s.id = ps.song_id
+	JOIN groups g ON g.id = s.group_id
+	WHERE ps.playlist_id = $1
+	ORDER BY ps.position;`
+
+	rows, err := q.QueryContext(ctx, query, playlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	playlist.Songs = []models.PlaylistSong{}
+
+	for rows.Next() {
+		var song models.PlaylistSong
+
+		err := rows.Scan(&song.Position, &song.SongID, &song.SongName, &song.GroupID, &song.GroupName, &song.Link, &song.AddedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		playlist.Songs = append(playlist.Songs, song)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	playlist.SongsCount = len(playlist.Songs)
+
+	return playlist, nil
+}
+
+// touchPlaylist locks the playlist, updates its updated_at and returns the number of its songs.
+func touchPlaylist(ctx context.Context, q querier, playlistID int64) (int, error) {
+	var id int64
+
+	if err := q.QueryRowContext(ctx, `UPDATE playlists SET updated_at = NOW() WHERE id = $1 RETURNING id;`, playlistID).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, storage.ErrPlaylistNotFound
+		}
+
+		return 0, err
+	}
+
+	var count int
+
+	if err := q.QueryRowContext(ctx, `SELECT COUNT(*) FROM playlist_songs WHERE playlist_id = $1;`, playlistID).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// compactPlaylists renumbers the songs of the playlists from 1 keeping their order,
+// it is called after songs are removed from them.
+func compactPlaylists(ctx context.Context, q querier, playlistIDs []int64) error {
+	if len(playlistIDs) == 0 {
+		return nil
+	}
+
+	query := `
+	UPDATE playlist_songs ps
+	SET position = o.position
+	FROM (
+		SELECT playlist_id, song_id, ROW_NUMBER() OVER (PARTITION BY playlist_id ORDER BY position) AS position
+		FROM playlist_songs
+		WHERE playlist_id = ANY($1)
+	) o
+	WHERE ps.playlist_id = o.playlist_id AND ps.song_id = o.song_id AND ps.position <> o.position;`
+
+	_, err := q.ExecContext(ctx, query, pq.Array(playlistIDs))
+
+	return err
+}
+
+// removeFromPlaylists deletes the song from all playlists and returns their ids.
+func removeFromPlaylists(ctx context.Context, q querier, songID int) ([]int64, error) {
+	rows, err := q.QueryContext(ctx, `DELETE FROM playlist_songs WHERE song_id = $1 RETURNING playlist_id;`, songID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var playlistIDs []int64
+
+	for rows.Next() {
+		var id int64
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		playlistIDs = append(playlistIDs, id)
+	}
+
+	return playlistIDs, rows.Err()
+}
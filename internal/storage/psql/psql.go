@@ -9,6 +9,7 @@ import (
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/lib/pq"
 	"log/slog"
 	"strings"
 	"test_task/internal/config"
@@ -16,8 +17,6 @@ import (
 	"test_task/internal/storage"
 	"test_task/pkg/e"
 	"time"
-
-	_ "github.com/lib/pq"
 )
 
 type Storage struct {
@@ -64,12 +63,15 @@ func New(cfg *config.Config, migratePath string) (*Storage, error) {
 	return &Storage{db: db}, nil
 }
 
+// SaveGroup saves the group and returns its id, the id of the group with the same normalized name
+// is returned if there is one.
 func (s *Storage) SaveGroup(ctx context.Context, groupName string) (int64, error) {
 	const fn = "psql.SaveGroup"
 
 	q := `
 	INSERT INTO groups (group_name)
 	VALUES ($1)
+	ON CONFLICT ((normalize_name(group_name))) DO UPDATE SET group_name = groups.group_name
 	RETURNING id;`
 
 	var groupID int64
@@ -81,35 +83,61 @@ func (s *Storage) SaveGroup(ctx context.Context, groupName string) (int64, error
 	return groupID, nil
 }
 
+// SaveSong saves the song and returns its id. If the group already has a song with the same
+// normalized name, the song is left as is and its id is returned.
 func (s *Storage) SaveSong(ctx context.Context, songInfo *storage.SongInfo) (int64, error) {
 	const fn = "psql.SaveSong"
 
 	q := `
-	INSERT INTO songs (song, release_date, song_text, link, group_id)
-	VALUES ($1, $2, $3, $4, $5)
+	INSERT INTO songs (song, release_date, song_text, link, group_id, status, platform, external_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (group_id, (normalize_name(song))) DO UPDATE SET song = songs.song
 	RETURNING id;`
 
+	status := songInfo.Status
+	if status == "" {
+		status = storage.SongStatusReady
+	}
+
 	args := []any{
 		songInfo.Song,
 		songInfo.Date,
 		songInfo.Text,
 		songInfo.Link,
 		songInfo.GroupID,
+		status,
+		songInfo.Platform,
+		songInfo.ExternalID,
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, e.Wrap(fn, err)
 	}
+	defer tx.Rollback()
 
 	var songID int64
 
-	if err := s.db.QueryRowContext(ctx, q, args...).Scan(&songID); err != nil {
+	if err := tx.QueryRowContext(ctx, q, args...).Scan(&songID); err != nil {
+		return 0, e.Wrap(fn, err)
+	}
+
+	if err := syncPrimaryLink(ctx, tx, songID); err != nil {
+		return 0, e.Wrap(fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
 		return 0, e.Wrap(fn, err)
 	}
 
 	return songID, nil
 }
 
+// GroupExists looks the group up by its normalized name.
 func (s *Storage) GroupExists(ctx context.Context, GroupName string) (int64, bool, error) {
 	const fn = "psql.GroupExists"
 
-	q := `SELECT id FROM groups WHERE group_name = $1;`
+	q := `SELECT id FROM groups WHERE normalize_name(group_name) = normalize_name($1);`
 
 	var groupID int64
 
@@ -124,10 +152,11 @@ func (s *Storage) GroupExists(ctx context.Context, GroupName string) (int64, boo
 	return groupID, true, nil
 }
 
+// SongExists looks the song of the group up by its normalized name.
 func (s *Storage) SongExists(ctx context.Context, SongName string, GroupID int64) (int64, bool, error) {
 	const fn = "psql.SongExists"
 
-	q := `SELECT id FROM songs WHERE song = $1 AND group_id = $2;`
+	q := `SELECT id FROM songs WHERE normalize_name(song) = normalize_name($1) AND group_id = $2;`
 
 	var songID int64
 
@@ -142,12 +171,25 @@ func (s *Storage) SongExists(ctx context.Context, SongName string, GroupID int64
 	return songID, true, nil
 }
 
+// DeleteSong deletes the song, its other rows are deleted by cascade
+// and the playlists it was in are renumbered to keep their positions without gaps.
 func (s *Storage) DeleteSong(ctx context.Context, songID int) error {
 	const fn = "psql.DeleteSong"
 
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+	defer tx.Rollback()
+
+	playlistIDs, err := removeFromPlaylists(ctx, tx, songID)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
 	q := `DELETE FROM songs WHERE id = $1;`
 
-	res, err := s.db.ExecContext(ctx, q, songID)
+	res, err := tx.ExecContext(ctx, q, songID)
 	if err != nil {
 		return e.Wrap(fn, err)
 	}
@@ -161,6 +203,14 @@ func (s *Storage) DeleteSong(ctx context.Context, songID int) error {
 		return e.Wrap(fn, storage.ErrSongNotFound)
 	}
 
+	if err := compactPlaylists(ctx, tx, playlistIDs); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return e.Wrap(fn, err)
+	}
+
 	return nil
 }
 
@@ -184,19 +234,151 @@ func (s *Storage) GetSongText(ctx context.Context, songID int64) (*models.SongTe
 	return &songResp, nil
 }
 
-func (s *Storage) GetLibrary(ctx context.Context, filters *storage.GetLibraryFilters) (map[int64]*models.Group, error) {
+// GetLibrary returns the groups in the order of their first song, the songs are ordered by id
+// or by the average rating with filters.SortBy.
+func (s *Storage) GetLibrary(ctx context.Context, filters *storage.GetLibraryFilters) ([]models.Group, error) {
 	const fn = "psql.GetLibrary"
 
+	favorited := "FALSE"
+
+	if filters.UserID != 0 {
+		favorited = "f.user_id IS NOT NULL"
+	}
+
+	from, args, paramIndex, err := libraryQuery(filters)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
 	query := `
-	SELECT g.id, g.group_name, s.id, s.song, s.release_date, s.song_text, s.link 
-	FROM groups g
-	LEFT JOIN songs s ON g.id = s.group_id
-	`
+	SELECT g.id, g.group_name, s.id, s.song, s.release_date, s.song_text, s.link, s.status, s.link_status, s.platform, s.external_id,
+		COALESCE(r.average, 0), COALESCE(r.count, 0), ` + favorited + `,
+		COALESCE(a.id, 0), COALESCE(a.title, ''), a.release_date, COALESCE(s.track_number, 0),
+		COALESCE(s.duration, 0), COALESCE(s.bpm, 0)::FLOAT8, s.musical_key, s.language, s.explicit,
+		s.attributes, g.attributes
+	` + from
+
+	switch {
+	case filters.SortBy == storage.SortByRating:
+		query += " ORDER BY r.average DESC NULLS LAST, r.count DESC NULLS LAST, g.id, s.id"
+	case filters.AlbumsView:
+		query += " ORDER BY g.id, a.release_date NULLS LAST, a.id NULLS LAST, s.track_number NULLS LAST, s.id"
+	default:
+		query += " ORDER BY g.id, s.id"
+	}
+
+	if filters.Offset != 0 {
+		query += fmt.Sprintf(" OFFSET $%d", paramIndex)
+		args = append(args, filters.Offset)
+		paramIndex++
+	}
+
+	if filters.Limit != 0 {
+		query += fmt.Sprintf(" LIMIT $%d", paramIndex)
+		args = append(args, filters.Limit)
+		paramIndex++
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
 
+	var groups []models.Group
+	groupIndex := make(map[int64]int)
+	albumDates := make(map[int64]string)
+
+	for rows.Next() {
+		var (
+			g   models.Group
+			s   models.Song
+			rd  time.Time
+			ard sql.NullTime
+
+			songAttributes, groupAttributes []byte
+		)
+
+		err := rows.Scan(&g.GroupID, &g.GroupName, &s.SongID, &s.SongName, &rd, &s.SongText, &s.Link, &s.Status, &s.LinkStatus, &s.Platform, &s.ExternalID,
+			&s.AverageRating, &s.RatingsCount, &s.Favorited, &s.AlbumID, &s.AlbumTitle, &ard, &s.TrackNumber,
+			&s.Duration, &s.BPM, &s.Key, &s.Language, &s.Explicit, &songAttributes, &groupAttributes)
+		if err != nil {
+			continue
+		}
+
+		s.ReleaseDate = rd.Format("02.01.2006")
+		albumDates[s.AlbumID] = formatDate(ard)
+
+		if s.Attributes, err = decodeAttributes(songAttributes); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		i, exists := groupIndex[g.GroupID]
+		if !exists {
+			if g.Attributes, err = decodeAttributes(groupAttributes); err != nil {
+				return nil, e.Wrap(fn, err)
+			}
+
+			i = len(groups)
+			groupIndex[g.GroupID] = i
+
+			groups = append(groups, models.Group{
+				GroupID:    g.GroupID,
+				GroupName:  g.GroupName,
+				SongInfo:   []models.Song{},
+				Attributes: g.Attributes,
+			})
+		}
+
+		groups[i].SongInfo = append(groups[i].SongInfo, s)
+	}
+
+	if len(groups) == 0 {
+		return nil, e.Wrap(fn, storage.ErrNothingFound)
+	}
+
+	if err := s.attachSongLinks(ctx, groups); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if err := s.attachSongTags(ctx, groups); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if filters.AlbumsView {
+		for i := range groups {
+			nestAlbums(&groups[i], albumDates)
+		}
+	}
+
+	return groups, nil
+}
+
+// libraryQuery builds the FROM and WHERE clauses of the library query with their arguments,
+// paramIndex is the index of the next argument.
+func libraryQuery(filters *storage.GetLibraryFilters) (string, []interface{}, int, error) {
 	var args []interface{}
 	var sets []string
 	paramIndex := 1
 
+	favoritesJoin := ""
+
+	if filters.UserID != 0 {
+		favoritesJoin = fmt.Sprintf("LEFT JOIN favorites f ON f.song_id = s.id AND f.user_id = $%d", paramIndex)
+		args = append(args, filters.UserID)
+		paramIndex++
+	}
+
+	query := `
+	FROM groups g
+	LEFT JOIN songs s ON g.id = s.group_id
+	LEFT JOIN albums a ON a.id = s.album_id
+	LEFT JOIN (
+		SELECT song_id, AVG(rating)::FLOAT8 AS average, COUNT(*) AS count FROM song_ratings GROUP BY song_id
+	) r ON r.song_id = s.id
+	` + favoritesJoin + `
+	`
+
 	if filters.GroupName != "" {
 		sets = append(sets, fmt.Sprintf("g.group_name = $%d", paramIndex))
 		args = append(args, filters.GroupName)
@@ -232,64 +414,179 @@ func (s *Storage) GetLibrary(ctx context.Context, filters *storage.GetLibraryFil
 		args = append(args, filters.Link)
 		paramIndex++
 	}
+	if filters.LinkStatus != "" {
+		sets = append(sets, fmt.Sprintf("s.link_status = $%d", paramIndex))
+		args = append(args, filters.LinkStatus)
+		paramIndex++
+	}
+	if filters.Platform != "" {
+		sets = append(sets, fmt.Sprintf("s.platform = $%d", paramIndex))
+		args = append(args, filters.Platform)
+		paramIndex++
+	}
+	if filters.Favorited && filters.UserID != 0 {
+		sets = append(sets, "f.user_id IS NOT NULL")
+	}
+	if filters.AlbumID != 0 {
+		sets = append(sets, fmt.Sprintf("a.id = $%d", paramIndex))
+		args = append(args, filters.AlbumID)
+		paramIndex++
+	}
+	if filters.AlbumTitle != "" {
+		sets = append(sets, fmt.Sprintf("a.title = $%d", paramIndex))
+		args = append(args, filters.AlbumTitle)
+		paramIndex++
+	}
+	if filters.Artist != "" {
+		sets = append(sets, fmt.Sprintf(`(EXISTS (
+			SELECT 1 FROM group_members gm JOIN artists ar ON ar.id = gm.artist_id
+			WHERE gm.group_id = g.id AND LOWER(ar.name) = LOWER($%[1]d)
+		) OR EXISTS (
+			SELECT 1 FROM song_credits sc JOIN artists ar ON ar.id = sc.artist_id
+			WHERE sc.song_id = s.id AND LOWER(ar.name) = LOWER($%[1]d)
+		))`, paramIndex))
+		args = append(args, filters.Artist)
+		paramIndex++
+	}
+	if filters.ArtistID != 0 {
+		sets = append(sets, fmt.Sprintf(`(EXISTS (
+			SELECT 1 FROM group_members gm WHERE gm.group_id = g.id AND gm.artist_id = $%[1]d
+		) OR EXISTS (
+			SELECT 1 FROM song_credits sc WHERE sc.song_id = s.id AND sc.artist_id = $%[1]d
+		))`, paramIndex))
+		args = append(args, filters.ArtistID)
+		paramIndex++
+	}
 
-	if len(sets) > 0 {
-		query += "WHERE "
-		query += strings.Join(sets, "AND ")
+	if filters.DurationMin != 0 {
+		sets = append(sets, fmt.Sprintf("s.duration >= $%d", paramIndex))
+		args = append(args, filters.DurationMin)
+		paramIndex++
+	}
+	if filters.DurationMax != 0 {
+		sets = append(sets, fmt.Sprintf("s.duration <= $%d", paramIndex))
+		args = append(args, filters.DurationMax)
+		paramIndex++
+	}
+	if filters.BPMMin != 0 {
+		sets = append(sets, fmt.Sprintf("s.bpm >= $%d", paramIndex))
+		args = append(args, filters.BPMMin)
+		paramIndex++
+	}
+	if filters.BPMMax != 0 {
+		sets = append(sets, fmt.Sprintf("s.bpm <= $%d", paramIndex))
+		args = append(args, filters.BPMMax)
+		paramIndex++
+	}
+	if filters.Key != "" {
+		sets = append(sets, fmt.Sprintf("s.musical_key = $%d", paramIndex))
+		args = append(args, filters.Key)
+		paramIndex++
+	}
+	if filters.Language != "" {
+		sets = append(sets, fmt.Sprintf("s.language = $%d", paramIndex))
+		args = append(args, filters.Language)
+		paramIndex++
+	}
+	if filters.Explicit != nil {
+		sets = append(sets, fmt.Sprintf("s.explicit = $%d", paramIndex))
+		args = append(args, *filters.Explicit)
+		paramIndex++
 	}
 
-	query += fmt.Sprintf(" ORDER BY g.id")
+	if tags := libraryTags(filters); len(tags) > 0 {
+		var tagSets []string
 
-	if filters.Offset != 0 {
-		query += fmt.Sprintf(" OFFSET $%d", paramIndex)
-		args = append(args, filters.Offset)
-		paramIndex++
+		for _, tag := range tags {
+			tagSets = append(tagSets, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM song_tag_links stl JOIN tags t ON t.id = stl.tag_id
+			WHERE stl.song_id = s.id AND t.kind = $%d AND t.name = $%d
+		)`, paramIndex, paramIndex+1))
+			args = append(args, tag.kind, tag.name)
+			paramIndex += 2
+		}
+
+		if filters.TagsMatchAny {
+			sets = append(sets, "("+strings.Join(tagSets, " OR ")+")")
+		} else {
+			sets = append(sets, tagSets...)
+		}
 	}
 
-	if filters.Limit != 0 {
-		query += fmt.Sprintf(" LIMIT $%d", paramIndex)
-		args = append(args, filters.Limit)
-		paramIndex++
+	switch filters.Covers {
+	case storage.CoversExclude:
+		sets = append(sets, "NOT EXISTS (SELECT 1 FROM song_relations sr WHERE sr.song_id = s.id AND sr.type = 'cover_of')")
+	case storage.CoversOnly:
+		sets = append(sets, "EXISTS (SELECT 1 FROM song_relations sr WHERE sr.song_id = s.id AND sr.type = 'cover_of')")
 	}
 
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	attributeSets, err := attributeConditions(filters.Attributes, &args, &paramIndex)
 	if err != nil {
-		return nil, e.Wrap(fn, err)
+		return "", nil, 0, err
 	}
-	defer rows.Close()
 
-	groupMap := make(map[int64]*models.Group)
+	sets = append(sets, attributeSets...)
 
-	for rows.Next() {
-		var (
-			g  models.Group
-			s  models.Song
-			rd time.Time
-		)
+	if len(sets) > 0 {
+		query += "WHERE "
+		query += strings.Join(sets, " AND ")
+	}
 
-		err := rows.Scan(&g.GroupID, &g.GroupName, &s.SongID, &s.SongName, &rd, &s.SongText, &s.Link)
-		if err != nil {
-			continue
-		}
+	return query, args, paramIndex, nil
+}
 
-		s.ReleaseDate = rd.Format("02.01.2006")
+type libraryTag struct {
+	kind string
+	name string
+}
 
-		if _, exists := groupMap[g.GroupID]; !exists {
-			groupMap[g.GroupID] = &models.Group{
-				GroupID:   g.GroupID,
-				GroupName: g.GroupName,
-				SongInfo:  []models.Song{},
-			}
-		}
+func libraryTags(filters *storage.GetLibraryFilters) []libraryTag {
+	var tags []libraryTag
 
-		groupMap[g.GroupID].SongInfo = append(groupMap[g.GroupID].SongInfo, s)
+	for _, name := range filters.Genres {
+		tags = append(tags, libraryTag{kind: storage.TagKindGenre, name: name})
+	}
+	for _, name := range filters.Tags {
+		tags = append(tags, libraryTag{kind: storage.TagKindTag, name: name})
 	}
 
-	if len(groupMap) == 0 {
-		return nil, e.Wrap(fn, storage.ErrNothingFound)
+	return tags
+}
+
+// nestAlbums moves the songs of the group into its albums keeping their order,
+// the songs without an album stay in SongInfo.
+func nestAlbums(group *models.Group, albumDates map[int64]string) {
+	albumIndex := make(map[int64]int)
+	singles := []models.Song{}
+
+	group.Albums = []models.Album{}
+
+	for _, song := range group.SongInfo {
+		if song.AlbumID == 0 {
+			singles = append(singles, song)
+
+			continue
+		}
+
+		i, exists := albumIndex[song.AlbumID]
+		if !exists {
+			i = len(group.Albums)
+			albumIndex[song.AlbumID] = i
+
+			group.Albums = append(group.Albums, models.Album{
+				AlbumID:     song.AlbumID,
+				GroupID:     group.GroupID,
+				Title:       song.AlbumTitle,
+				ReleaseDate: albumDates[song.AlbumID],
+				Songs:       []models.Song{},
+			})
+		}
+
+		group.Albums[i].Songs = append(group.Albums[i].Songs, song)
+		group.Albums[i].SongsCount++
 	}
 
-	return groupMap, nil
+	group.SongInfo = singles
 }
 
 func (s *Storage) UpdateSong(ctx context.Context, songID int, songInfo *storage.SongInfo) error {
@@ -319,6 +616,58 @@ func (s *Storage) UpdateSong(ctx context.Context, songID int, songInfo *storage.
 		sets = append(sets, fmt.Sprintf("link = $%d", paramIndex))
 		args = append(args, songInfo.Link)
 		paramIndex++
+
+		sets = append(sets, fmt.Sprintf("platform = $%d, external_id = $%d", paramIndex, paramIndex+1))
+		args = append(args, songInfo.Platform, songInfo.ExternalID)
+		paramIndex += 2
+
+		// the new link is checked again by the link checker
+		sets = append(sets, "link_status = 'unknown'", "link_checked_at = NULL")
+	}
+	if songInfo.Status != "" {
+		sets = append(sets, fmt.Sprintf("status = $%d", paramIndex))
+		args = append(args, songInfo.Status)
+		paramIndex++
+	}
+	if songInfo.Album != "" {
+		// the album is created by upsertAlbum below
+		sets = append(sets, fmt.Sprintf("album_id = (SELECT id FROM albums WHERE group_id = songs.group_id AND title = $%d)", paramIndex))
+		args = append(args, songInfo.Album)
+		paramIndex++
+	} else if songInfo.AlbumID != nil {
+		sets = append(sets, fmt.Sprintf("album_id = $%d", paramIndex))
+		args = append(args, sql.NullInt64{Int64: *songInfo.AlbumID, Valid: *songInfo.AlbumID != 0})
+		paramIndex++
+	}
+	if songInfo.TrackNumber != nil {
+		sets = append(sets, fmt.Sprintf("track_number = $%d", paramIndex))
+		args = append(args, sql.NullInt64{Int64: int64(*songInfo.TrackNumber), Valid: *songInfo.TrackNumber != 0})
+		paramIndex++
+	}
+	if songInfo.Duration != nil {
+		sets = append(sets, fmt.Sprintf("duration = $%d", paramIndex))
+		args = append(args, sql.NullInt64{Int64: int64(*songInfo.Duration), Valid: *songInfo.Duration != 0})
+		paramIndex++
+	}
+	if songInfo.BPM != nil {
+		sets = append(sets, fmt.Sprintf("bpm = $%d", paramIndex))
+		args = append(args, sql.NullFloat64{Float64: *songInfo.BPM, Valid: *songInfo.BPM != 0})
+		paramIndex++
+	}
+	if songInfo.Key != nil {
+		sets = append(sets, fmt.Sprintf("musical_key = $%d", paramIndex))
+		args = append(args, *songInfo.Key)
+		paramIndex++
+	}
+	if songInfo.Language != nil {
+		sets = append(sets, fmt.Sprintf("language = $%d", paramIndex))
+		args = append(args, *songInfo.Language)
+		paramIndex++
+	}
+	if songInfo.Explicit != nil {
+		sets = append(sets, fmt.Sprintf("explicit = $%d", paramIndex))
+		args = append(args, *songInfo.Explicit)
+		paramIndex++
 	}
 
 	if len(sets) == 0 {
@@ -329,8 +678,26 @@ func (s *Storage) UpdateSong(ctx context.Context, songID int, songInfo *storage.
 	query += fmt.Sprintf(" WHERE id = $%d", paramIndex)
 	args = append(args, songID)
 
-	result, err := s.db.ExecContext(ctx, query, args...)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+	defer tx.Rollback()
+
+	if songInfo.Album != "" {
+		if _, err := upsertAlbum(ctx, tx, songID, songInfo.Album); err != nil {
+			return e.Wrap(fn, err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
+		var pqErr *pq.Error
+
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "songs_group_id_song_key" {
+			return e.Wrap(fn, storage.ErrSongExists)
+		}
+
 		return e.Wrap(fn, err)
 	}
 
@@ -343,5 +710,67 @@ func (s *Storage) UpdateSong(ctx context.Context, songID int, songInfo *storage.
 		return e.Wrap(fn, storage.ErrSongNotFound)
 	}
 
+	if songInfo.Link != "" {
+		if err := syncPrimaryLink(ctx, tx, int64(songID)); err != nil {
+			return e.Wrap(fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return e.Wrap(fn, err)
+	}
+
 	return nil
 }
+
+func (s *Storage) GetSong(ctx context.Context, songID int) (*storage.SongInfo, error) {
+	const fn = "psql.GetSong"
+
+	q := `
+	SELECT s.id, s.song, s.release_date, s.song_text, s.link, s.status, s.platform, s.external_id, g.id, g.group_name,
+		s.album_id, COALESCE(a.title, ''), s.track_number
+	FROM songs s
+	JOIN groups g ON g.id = s.group_id
+	LEFT JOIN albums a ON a.id = s.album_id
+	WHERE s.id = $1;`
+
+	var (
+		songInfo    storage.SongInfo
+		albumID     sql.NullInt64
+		trackNumber sql.NullInt64
+	)
+
+	err := s.db.QueryRowContext(ctx, q, songID).Scan(
+		&songInfo.SongID,
+		&songInfo.Song,
+		&songInfo.Date,
+		&songInfo.Text,
+		&songInfo.Link,
+		&songInfo.Status,
+		&songInfo.Platform,
+		&songInfo.ExternalID,
+		&songInfo.GroupID,
+		&songInfo.GroupName,
+		&albumID,
+		&songInfo.Album,
+		&trackNumber,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.Wrap(fn, storage.ErrSongNotFound)
+		}
+
+		return nil, e.Wrap(fn, err)
+	}
+
+	if albumID.Valid {
+		songInfo.AlbumID = &albumID.Int64
+	}
+
+	if trackNumber.Valid {
+		n := int(trackNumber.Int64)
+		songInfo.TrackNumber = &n
+	}
+
+	return &songInfo, nil
+}
@@ -0,0 +1,199 @@
+package psql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"test_task/pkg/e"
+	"time"
+)
+
+// songChangeColumns maps the fields of models.SongChange to the songs columns they update.
+var songChangeColumns = map[string]string{
+	"release_date": "release_date",
+	"song_text":    "song_text",
+	"link":         "link",
+	"platform":     "platform",
+	"external_id":  "external_id",
+	"album":        "album_id",
+	"track_number": "track_number",
+}
+
+// SongsToRefresh returns the songs to refresh, the ones that failed to refresh after incompleteBefore
+// are skipped and the failed ones go after the never tried ones.
+func (s *Storage) SongsToRefresh(ctx context.Context, refreshedBefore, incompleteBefore time.Time, limit int) ([]int, error) {
+	const fn = "psql.SongsToRefresh"
+
+	q := `
+	SELECT id FROM songs
+	WHERE status <> $1
+	AND (refreshed_at < $2 OR ((song_text = '' OR release_date = '0001-01-01') AND refreshed_at < $3))
+	AND (refresh_failed_at IS NULL OR refresh_failed_at < $3)
+	ORDER BY GREATEST(refreshed_at, refresh_failed_at)
+	LIMIT $4;`
+
+	rows, err := s.db.QueryContext(ctx, q, storage.SongStatusPending, refreshedBefore, incompleteBefore, limit)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	var ids []int
+
+	for rows.Next() {
+		var id int
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return ids, nil
+}
+
+// MarkRefreshFailed records a failed refresh of the song, so the scheduler backs off from it.
+func (s *Storage) MarkRefreshFailed(ctx context.Context, songID int) error {
+	const fn = "psql.MarkRefreshFailed"
+
+	if _, err := s.db.ExecContext(ctx, `UPDATE songs SET refresh_failed_at = NOW() WHERE id = $1;`, songID); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	return nil
+}
+
+// ApplySongChanges updates the changed fields, records every change and marks the song as refreshed
+// and ready, clearing a failed refresh. With no changes only the status and the refresh time are updated.
+func (s *Storage) ApplySongChanges(ctx context.Context, songID int, changes []models.SongChange) error {
+	const fn = "psql.ApplySongChanges"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+	defer tx.Rollback()
+
+	sets := []string{"status = 'ready'", "refreshed_at = NOW()", "refresh_failed_at = NULL"}
+	linkChanged := false
+	var args []interface{}
+	paramIndex := 1
+
+	for _, change := range changes {
+		column, ok := songChangeColumns[change.Field]
+		if !ok {
+			return e.Wrap(fn, fmt.Errorf("unknown field %q", change.Field))
+		}
+
+		var value any = change.NewValue
+
+		switch column {
+		case "release_date":
+			value, err = time.Parse("02.01.2006", change.NewValue)
+			if err != nil {
+				return e.Wrap(fn, err)
+			}
+		case "album_id":
+			value, err = upsertAlbum(ctx, tx, songID, change.NewValue)
+			if err != nil {
+				return e.Wrap(fn, err)
+			}
+		case "track_number":
+			value, err = strconv.Atoi(change.NewValue)
+			if err != nil {
+				return e.Wrap(fn, err)
+			}
+		}
+
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, paramIndex))
+		args = append(args, value)
+		paramIndex++
+
+		if column == "link" {
+			linkChanged = true
+			sets = append(sets, "link_status = 'unknown'", "link_checked_at = NULL")
+		}
+	}
+
+	query := "UPDATE songs SET " + strings.Join(sets, ", ")
+	query += fmt.Sprintf(" WHERE id = $%d", paramIndex)
+	args = append(args, songID)
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return e.Wrap(fn, storage.ErrSongNotFound)
+	}
+
+	if linkChanged {
+		if err := syncPrimaryLink(ctx, tx, int64(songID)); err != nil {
+			return e.Wrap(fn, err)
+		}
+	}
+
+	q := `
+	INSERT INTO song_changes (song_id, field, old_value, new_value, source)
+	VALUES ($1, $2, $3, $4, $5);`
+
+	for _, change := range changes {
+		if _, err := tx.ExecContext(ctx, q, songID, change.Field, change.OldValue, change.NewValue, change.Source); err != nil {
+			return e.Wrap(fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetSongChanges(ctx context.Context, songID int) ([]models.SongChange, error) {
+	const fn = "psql.GetSongChanges"
+
+	q := `
+	SELECT field, old_value, new_value, source, changed_at
+	FROM song_changes
+	WHERE song_id = $1
+	ORDER BY changed_at DESC, id DESC;`
+
+	rows, err := s.db.QueryContext(ctx, q, songID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	changes := []models.SongChange{}
+
+	for rows.Next() {
+		var change models.SongChange
+
+		if err := rows.Scan(&change.Field, &change.OldValue, &change.NewValue, &change.Source, &change.ChangedAt); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return changes, nil
+}
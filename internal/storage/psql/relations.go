@@ -0,0 +1,167 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"test_task/pkg/e"
+)
+
+// GetSongRelations returns the relations of the song in both directions.
+func (s *Storage) GetSongRelations(ctx context.Context, songID int) ([]models.SongRelation, error) {
+	const fn = "psql.GetSongRelations"
+
+	q := `
+	SELECT song_id, related_song_id, type, created_at
+	FROM song_relations
+	WHERE song_id = $1 OR related_song_id = $1
+	ORDER BY created_at, song_id, related_song_id;`
+
+	rows, err := s.db.QueryContext(ctx, q, songID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	relations, err := scanRelations(rows)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return relations, nil
+}
+
+// GetRelatedSongs returns the songs reachable from the song through at most depth relations
+// in any direction together with the relations between them.
+func (s *Storage) GetRelatedSongs(ctx context.Context, songID int, depth int) (*models.RelatedSongsResponse, error) {
+	const fn = "psql.GetRelatedSongs"
+
+	q := `
+	WITH RECURSIVE graph(song_id, distance) AS (
+		SELECT id, 0 FROM songs WHERE id = $1
+		UNION
+		SELECT CASE WHEN r.song_id = graph.song_id THEN r.related_song_id ELSE r.song_id END, graph.distance + 1
+		FROM graph
+		JOIN song_relations r ON r.song_id = graph.song_id OR r.related_song_id = graph.song_id
+		WHERE graph.distance < $2
+	)
+	SELECT s.id, s.song, g.id, g.group_name, MIN(graph.distance)
+	FROM graph
+	JOIN songs s ON s.id = graph.song_id
+	JOIN groups g ON g.id = s.group_id
+	GROUP BY s.id, g.id
+	ORDER BY MIN(graph.distance), s.id;`
+
+	rows, err := s.db.QueryContext(ctx, q, songID, depth)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	related := &models.RelatedSongsResponse{
+		SongID:    songID,
+		Songs:     []models.RelatedSong{},
+		Relations: []models.SongRelation{},
+	}
+
+	var songIDs []int64
+
+	for rows.Next() {
+		var song models.RelatedSong
+
+		if err := rows.Scan(&song.SongID, &song.SongName, &song.GroupID, &song.GroupName, &song.Distance); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		related.Songs = append(related.Songs, song)
+		songIDs = append(songIDs, song.SongID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if len(related.Songs) == 0 {
+		return nil, e.Wrap(fn, storage.ErrSongNotFound)
+	}
+
+	q = `
+	SELECT song_id, related_song_id, type, created_at
+	FROM song_relations
+	WHERE song_id = ANY($1) AND related_song_id = ANY($1)
+	ORDER BY song_id, related_song_id, type;`
+
+	relationRows, err := s.db.QueryContext(ctx, q, pq.Array(songIDs))
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer relationRows.Close()
+
+	related.Relations, err = scanRelations(relationRows)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return related, nil
+}
+
+// AddSongRelation marks the song as a cover, remix, live version or sample of the related song,
+// adding the same relation twice is not an error.
+func (s *Storage) AddSongRelation(ctx context.Context, songID int, relatedSongID int64, relationType string) error {
+	const fn = "psql.AddSongRelation"
+
+	q := `
+	INSERT INTO song_relations (song_id, related_song_id, type)
+	VALUES ($1, $2, $3)
+	ON CONFLICT DO NOTHING;`
+
+	if _, err := s.db.ExecContext(ctx, q, songID, relatedSongID, relationType); err != nil {
+		return e.Wrap(fn, artistForeignKeyErr(err))
+	}
+
+	return nil
+}
+
+func (s *Storage) RemoveSongRelation(ctx context.Context, songID int, relatedSongID int64, relationType string) error {
+	const fn = "psql.RemoveSongRelation"
+
+	q := `DELETE FROM song_relations WHERE song_id = $1 AND related_song_id = $2 AND type = $3;`
+
+	res, err := s.db.ExecContext(ctx, q, songID, relatedSongID, relationType)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return e.Wrap(fn, storage.ErrRelationNotFound)
+	}
+
+	return nil
+}
+
+func scanRelations(rows *sql.Rows) ([]models.SongRelation, error) {
+	relations := []models.SongRelation{}
+
+	for rows.Next() {
+		var relation models.SongRelation
+
+		if err := rows.Scan(&relation.SongID, &relation.RelatedSongID, &relation.Type, &relation.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		relations = append(relations, relation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return relations, nil
+}
@@ -0,0 +1,364 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"strings"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"test_task/pkg/e"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+const songLinkColumns = `id, song_id, type, label, url, platform, external_id, is_primary, created_at`
+
+func (s *Storage) GetSongLinks(ctx context.Context, songID int) ([]models.SongLink, error) {
+	const fn = "psql.GetSongLinks"
+
+	links, err := songLinks(ctx, s.db, []int64{int64(songID)})
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if links[int64(songID)] == nil {
+		return []models.SongLink{}, nil
+	}
+
+	return links[int64(songID)], nil
+}
+
+// CreateSongLink adds a link to the song, a primary link is also copied to songs.link.
+func (s *Storage) CreateSongLink(ctx context.Context, songID int, link *models.SongLink) (*models.SongLink, error) {
+	const fn = "psql.CreateSongLink"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer tx.Rollback()
+
+	if err := lockSong(ctx, tx, songID); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	q := `
+	INSERT INTO song_links (song_id, type, label, url, platform, external_id)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id;`
+
+	var linkID int64
+
+	err = tx.QueryRowContext(ctx, q, songID, link.Type, link.Label, link.URL, link.Platform, link.ExternalID).Scan(&linkID)
+	if err != nil {
+		return nil, e.Wrap(fn, uniqueLinkErr(err))
+	}
+
+	if link.Primary {
+		if err := setSongLink(ctx, tx, songID, link.URL, link.Platform, link.ExternalID); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+	}
+
+	created, err := getSongLink(ctx, tx, songID, linkID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return created, nil
+}
+
+// UpdateSongLink updates the link, making it primary or changing the url of the primary link
+// also updates songs.link, unsetting the primary flag clears it.
+func (s *Storage) UpdateSongLink(ctx context.Context, songID int, linkID int64, update *storage.SongLinkUpdate) (*models.SongLink, error) {
+	const fn = "psql.UpdateSongLink"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer tx.Rollback()
+
+	if err := lockSong(ctx, tx, songID); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	current, err := getSongLink(ctx, tx, songID, linkID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	var args []interface{}
+	var sets []string
+	paramIndex := 1
+
+	if update.Type != "" {
+		sets = append(sets, fmt.Sprintf("type = $%d", paramIndex))
+		args = append(args, update.Type)
+		paramIndex++
+	}
+	if update.Label != nil {
+		sets = append(sets, fmt.Sprintf("label = $%d", paramIndex))
+		args = append(args, *update.Label)
+		paramIndex++
+	}
+	if update.URL != "" {
+		sets = append(sets, fmt.Sprintf("url = $%d, platform = $%d, external_id = $%d", paramIndex, paramIndex+1, paramIndex+2),
+			"link_status = 'unknown'", "link_checked_at = NULL")
+		args = append(args, update.URL, update.Platform, update.ExternalID)
+		paramIndex += 3
+	}
+
+	if len(sets) == 0 && update.Primary == nil {
+		return nil, e.Wrap(fn, storage.ErrNoFieldsUpdate)
+	}
+
+	if len(sets) > 0 {
+		query := "UPDATE song_links SET " + strings.Join(sets, ", ")
+		query += fmt.Sprintf(" WHERE id = $%d", paramIndex)
+		args = append(args, linkID)
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return nil, e.Wrap(fn, uniqueLinkErr(err))
+		}
+	}
+
+	updated, err := getSongLink(ctx, tx, songID, linkID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	primary := current.Primary
+	if update.Primary != nil {
+		primary = *update.Primary
+	}
+
+	switch {
+	case primary && (!current.Primary || updated.URL != current.URL):
+		err = setSongLink(ctx, tx, songID, updated.URL, updated.Platform, updated.ExternalID)
+	case !primary && current.Primary:
+		err = setSongLink(ctx, tx, songID, "", "", "")
+	}
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	updated, err = getSongLink(ctx, tx, songID, linkID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return updated, nil
+}
+
+// DeleteSongLink deletes the link, deleting the primary link clears songs.link.
+func (s *Storage) DeleteSongLink(ctx context.Context, songID int, linkID int64) error {
+	const fn = "psql.DeleteSongLink"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return e.Wrap(fn, err)
+	}
+	defer tx.Rollback()
+
+	if err := lockSong(ctx, tx, songID); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	var primary bool
+
+	q := `DELETE FROM song_links WHERE id = $1 AND song_id = $2 RETURNING is_primary;`
+
+	if err := tx.QueryRowContext(ctx, q, linkID, songID).Scan(&primary); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return e.Wrap(fn, storage.ErrLinkNotFound)
+		}
+
+		return e.Wrap(fn, err)
+	}
+
+	if primary {
+		if err := setSongLink(ctx, tx, songID, "", "", ""); err != nil {
+			return e.Wrap(fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	return nil
+}
+
+// attachSongLinks loads the links of all songs in the library.
+func (s *Storage) attachSongLinks(ctx context.Context, groups []models.Group) error {
+	var songIDs []int64
+
+	for _, group := range groups {
+		for _, song := range group.SongInfo {
+			songIDs = append(songIDs, song.SongID)
+		}
+	}
+
+	links, err := songLinks(ctx, s.db, songIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		for i := range group.SongInfo {
+			group.SongInfo[i].Links = links[group.SongInfo[i].SongID]
+
+			if group.SongInfo[i].Links == nil {
+				group.SongInfo[i].Links = []models.SongLink{}
+			}
+		}
+	}
+
+	return nil
+}
+
+// syncPrimaryLink makes songs.link the primary song link, adding it to song_links if needed.
+// It must be called after every change of songs.link.
+func syncPrimaryLink(ctx context.Context, q querier, songID int64) error {
+	unset := `
+	UPDATE song_links SET is_primary = FALSE
+	WHERE song_id = $1 AND is_primary AND url <> (SELECT link FROM songs WHERE id = $1);`
+
+	if _, err := q.ExecContext(ctx, unset, songID); err != nil {
+		return err
+	}
+
+	// the type of a new primary link is guessed from the platform
+	upsert := `
+	INSERT INTO song_links (song_id, type, url, platform, external_id, is_primary)
+	SELECT id,
+		CASE platform WHEN 'youtube' THEN 'video' WHEN '' THEN 'other' WHEN 'other' THEN 'other' ELSE 'streaming' END,
+		link, platform, external_id, TRUE
+	FROM songs
+	WHERE id = $1 AND link <> '' AND link <> 'NULL'
+	ON CONFLICT (song_id, url) DO UPDATE
+	SET is_primary = TRUE, platform = EXCLUDED.platform, external_id = EXCLUDED.external_id;`
+
+	_, err := q.ExecContext(ctx, upsert, songID)
+
+	return err
+}
+
+// setSongLink sets songs.link, the link is checked again by the link checker.
+func setSongLink(ctx context.Context, q querier, songID int, link, platform, externalID string) error {
+	update := `
+	UPDATE songs
+	SET link = $1, platform = $2, external_id = $3, link_status = 'unknown', link_checked_at = NULL
+	WHERE id = $4;`
+
+	if _, err := q.ExecContext(ctx, update, link, platform, externalID, songID); err != nil {
+		return err
+	}
+
+	return syncPrimaryLink(ctx, q, int64(songID))
+}
+
+// lockSong locks the song row, so concurrent changes of the primary link are serialized.
+func lockSong(ctx context.Context, q querier, songID int) error {
+	var id int
+
+	if err := q.QueryRowContext(ctx, `SELECT id FROM songs WHERE id = $1 FOR UPDATE;`, songID).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrSongNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func getSongLink(ctx context.Context, q querier, songID int, linkID int64) (*models.SongLink, error) {
+	query := `SELECT ` + songLinkColumns + ` FROM song_links WHERE id = $1 AND song_id = $2;`
+
+	var (
+		link models.SongLink
+		id   int64
+	)
+
+	err := q.QueryRowContext(ctx, query, linkID, songID).Scan(
+		&link.LinkID, &id, &link.Type, &link.Label, &link.URL,
+		&link.Platform, &link.ExternalID, &link.Primary, &link.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrLinkNotFound
+		}
+
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+// songLinks returns the links of the songs by song id, the primary link goes first.
+func songLinks(ctx context.Context, q querier, songIDs []int64) (map[int64][]models.SongLink, error) {
+	query := `
+	SELECT ` + songLinkColumns + `
+	FROM song_links
+	WHERE song_id = ANY($1)
+	ORDER BY song_id, is_primary DESC, id;`
+
+	rows, err := q.QueryContext(ctx, query, pq.Array(songIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := make(map[int64][]models.SongLink)
+
+	for rows.Next() {
+		var (
+			link   models.SongLink
+			songID int64
+		)
+
+		err := rows.Scan(
+			&link.LinkID, &songID, &link.Type, &link.Label, &link.URL,
+			&link.Platform, &link.ExternalID, &link.Primary, &link.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		links[songID] = append(links[songID], link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+func uniqueLinkErr(err error) error {
+	var pqErr *pq.Error
+
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return storage.ErrLinkExists
+	}
+
+	return err
+}
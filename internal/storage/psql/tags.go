@@ -0,0 +1,341 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"test_task/pkg/e"
+)
+
+// GetTags returns the tags of the kind, all tags with an empty kind.
+func (s *Storage) GetTags(ctx context.Context, kind string) ([]models.Tag, error) {
+	const fn = "psql.GetTags"
+
+	q := `
+	SELECT t.id, t.kind, t.name, COUNT(stl.song_id)
+	FROM tags t
+	LEFT JOIN song_tag_links stl ON stl.tag_id = t.id
+	WHERE $1 = '' OR t.kind = $1
+	GROUP BY t.id
+	ORDER BY t.kind, t.name;`
+
+	rows, err := s.db.QueryContext(ctx, q, kind)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+
+	for rows.Next() {
+		var tag models.Tag
+
+		if err := rows.Scan(&tag.TagID, &tag.Kind, &tag.Name, &tag.SongsCount); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return tags, nil
+}
+
+// GetSongTags returns the tags of the song and the ones it inherits from its group.
+func (s *Storage) GetSongTags(ctx context.Context, songID int) (*models.SongTagsResponse, error) {
+	const fn = "psql.GetSongTags"
+
+	var groupID int64
+
+	if err := s.db.QueryRowContext(ctx, `SELECT group_id FROM songs WHERE id = $1;`, songID).Scan(&groupID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.Wrap(fn, storage.ErrSongNotFound)
+		}
+
+		return nil, e.Wrap(fn, err)
+	}
+
+	own, err := tagSet(ctx, s.db, "song_tags", "song_id", songID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	inherited, err := tagSet(ctx, s.db, "group_tags", "group_id", groupID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return &models.SongTagsResponse{
+		SongID:    songID,
+		Genres:    own.Genres,
+		Tags:      own.Tags,
+		Inherited: *inherited,
+	}, nil
+}
+
+// SetSongTags replaces the tags of the song, unknown tags are created.
+func (s *Storage) SetSongTags(ctx context.Context, songID int, tags *models.TagSet) error {
+	const fn = "psql.SetSongTags"
+
+	if err := s.replaceTags(ctx, "songs", "song_tags", "song_id", songID, tags, storage.ErrSongNotFound); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetGroupTags(ctx context.Context, groupID int64) (*models.GroupTagsResponse, error) {
+	const fn = "psql.GetGroupTags"
+
+	var exists bool
+
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM groups WHERE id = $1);`, groupID).Scan(&exists); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	if !exists {
+		return nil, e.Wrap(fn, storage.ErrGroupNotFound)
+	}
+
+	tags, err := tagSet(ctx, s.db, "group_tags", "group_id", groupID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return &models.GroupTagsResponse{
+		GroupID: groupID,
+		Genres:  tags.Genres,
+		Tags:    tags.Tags,
+	}, nil
+}
+
+// SetGroupTags replaces the tags of the group, unknown tags are created.
+func (s *Storage) SetGroupTags(ctx context.Context, groupID int64, tags *models.TagSet) error {
+	const fn = "psql.SetGroupTags"
+
+	if err := s.replaceTags(ctx, "groups", "group_tags", "group_id", groupID, tags, storage.ErrGroupNotFound); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	return nil
+}
+
+// GetLibraryFacets counts the songs of the library matching the filters for every tag they have,
+// the most used tags go first. Offset and limit of the filters are ignored.
+func (s *Storage) GetLibraryFacets(ctx context.Context, filters *storage.GetLibraryFilters) (*models.Facets, error) {
+	const fn = "psql.GetLibraryFacets"
+
+	from, args, _, err := libraryQuery(filters)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	q := `
+	SELECT t.kind, t.name, COUNT(*)
+	FROM song_tag_links stl
+	JOIN tags t ON t.id = stl.tag_id
+	WHERE stl.song_id IN (SELECT s.id ` + from + `)
+	GROUP BY t.kind, t.name
+	ORDER BY COUNT(*) DESC, t.name;`
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	facets := &models.Facets{
+		Genres: []models.Facet{},
+		Tags:   []models.Facet{},
+	}
+
+	for rows.Next() {
+		var (
+			kind  string
+			facet models.Facet
+		)
+
+		if err := rows.Scan(&kind, &facet.Name, &facet.Count); err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		if kind == storage.TagKindGenre {
+			facets.Genres = append(facets.Genres, facet)
+		} else {
+			facets.Tags = append(facets.Tags, facet)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return facets, nil
+}
+
+// attachSongTags sets the genres and tags of the library songs, including the inherited ones.
+func (s *Storage) attachSongTags(ctx context.Context, groups []models.Group) error {
+	var songIDs []int64
+
+	for _, group := range groups {
+		for _, song := range group.SongInfo {
+			songIDs = append(songIDs, song.SongID)
+		}
+	}
+
+	q := `
+	SELECT stl.song_id, t.kind, t.name
+	FROM song_tag_links stl
+	JOIN tags t ON t.id = stl.tag_id
+	WHERE stl.song_id = ANY($1)
+	ORDER BY stl.song_id, t.name;`
+
+	rows, err := s.db.QueryContext(ctx, q, pq.Array(songIDs))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	tags := make(map[int64]*models.TagSet)
+
+	for rows.Next() {
+		var (
+			songID     int64
+			kind, name string
+		)
+
+		if err := rows.Scan(&songID, &kind, &name); err != nil {
+			return err
+		}
+
+		set, ok := tags[songID]
+		if !ok {
+			set = &models.TagSet{}
+			tags[songID] = set
+		}
+
+		if kind == storage.TagKindGenre {
+			set.Genres = append(set.Genres, name)
+		} else {
+			set.Tags = append(set.Tags, name)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		for i := range group.SongInfo {
+			if set, ok := tags[group.SongInfo[i].SongID]; ok {
+				group.SongInfo[i].Genres = set.Genres
+				group.SongInfo[i].Tags = set.Tags
+			}
+		}
+	}
+
+	return nil
+}
+
+// replaceTags replaces the tags of the row id of the table in the join table joinTable,
+// notFound is returned if there is no such row.
+func (s *Storage) replaceTags(ctx context.Context, table, joinTable, column string, id interface{}, tags *models.TagSet, notFound error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM `+table+` WHERE id = $1);`, id).Scan(&exists); err != nil {
+		return err
+	}
+
+	if !exists {
+		return notFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM `+joinTable+` WHERE `+column+` = $1;`, id); err != nil {
+		return err
+	}
+
+	createTags := `
+	INSERT INTO tags (kind, name)
+	SELECT $1::TEXT, UNNEST($2::TEXT[])
+	ON CONFLICT (kind, name) DO NOTHING;`
+
+	linkTags := `
+	INSERT INTO ` + joinTable + ` (` + column + `, tag_id)
+	SELECT $1::INTEGER, id FROM tags WHERE kind = $2 AND name = ANY($3)
+	ON CONFLICT DO NOTHING;`
+
+	for _, tag := range []struct {
+		kind  string
+		names []string
+	}{
+		{kind: storage.TagKindGenre, names: tags.Genres},
+		{kind: storage.TagKindTag, names: tags.Tags},
+	} {
+		if len(tag.names) == 0 {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, createTags, tag.kind, pq.Array(tag.names)); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, linkTags, id, tag.kind, pq.Array(tag.names)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// tagSet returns the tags linked to the row id in the join table.
+func tagSet(ctx context.Context, q querier, joinTable, column string, id interface{}) (*models.TagSet, error) {
+	query := `
+	SELECT t.kind, t.name
+	FROM ` + joinTable + ` jt
+	JOIN tags t ON t.id = jt.tag_id
+	WHERE jt.` + column + ` = $1
+	ORDER BY t.name;`
+
+	rows, err := q.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	set := &models.TagSet{
+		Genres: []string{},
+		Tags:   []string{},
+	}
+
+	for rows.Next() {
+		var kind, name string
+
+		if err := rows.Scan(&kind, &name); err != nil {
+			return nil, err
+		}
+
+		if kind == storage.TagKindGenre {
+			set.Genres = append(set.Genres, name)
+		} else {
+			set.Tags = append(set.Tags, name)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
@@ -0,0 +1,193 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"test_task/internal/models"
+	"test_task/internal/storage"
+	"test_task/pkg/e"
+)
+
+// EnsureUser returns the user with the subject, creating it on the first call.
+func (s *Storage) EnsureUser(ctx context.Context, subject string) (*models.User, error) {
+	const fn = "psql.EnsureUser"
+
+	q := `
+	INSERT INTO users (subject)
+	VALUES ($1)
+	ON CONFLICT (subject) DO UPDATE SET subject = EXCLUDED.subject
+	RETURNING id, subject, created_at;`
+
+	var user models.User
+
+	if err := s.db.QueryRowContext(ctx, q, subject).Scan(&user.UserID, &user.Subject, &user.CreatedAt); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return &user, nil
+}
+
+// GetUserBySubject returns the user with the subject without creating it.
+func (s *Storage) GetUserBySubject(ctx context.Context, subject string) (*models.User, error) {
+	const fn = "psql.GetUserBySubject"
+
+	q := `SELECT id, subject, created_at FROM users WHERE subject = $1;`
+
+	var user models.User
+
+	if err := s.db.QueryRowContext(ctx, q, subject).Scan(&user.UserID, &user.Subject, &user.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.Wrap(fn, storage.ErrUserNotFound)
+		}
+
+		return nil, e.Wrap(fn, err)
+	}
+
+	return &user, nil
+}
+
+func (s *Storage) AddFavorite(ctx context.Context, userID int64, songID int) error {
+	const fn = "psql.AddFavorite"
+
+	q := `
+	INSERT INTO favorites (user_id, song_id)
+	VALUES ($1, $2)
+	ON CONFLICT DO NOTHING;`
+
+	if _, err := s.db.ExecContext(ctx, q, userID, songID); err != nil {
+		return e.Wrap(fn, songForeignKeyErr(err))
+	}
+
+	return nil
+}
+
+func (s *Storage) RemoveFavorite(ctx context.Context, userID int64, songID int) error {
+	const fn = "psql.RemoveFavorite"
+
+	q := `DELETE FROM favorites WHERE user_id = $1 AND song_id = $2;`
+
+	if _, err := s.db.ExecContext(ctx, q, userID, songID); err != nil {
+		return e.Wrap(fn, err)
+	}
+
+	return nil
+}
+
+// GetFavorites returns the favorite songs of the user, the latest first.
+func (s *Storage) GetFavorites(ctx context.Context, userID int64, offset, limit int) ([]models.FavoriteSong, error) {
+	const fn = "psql.GetFavorites"
+
+	q := `
+	SELECT s.id, s.song, g.id, g.group_name, s.link,
+		COALESCE(r.average, 0), COALESCE(r.count, 0), COALESCE(ur.rating, 0), f.created_at
+	FROM favorites f
+	JOIN songs s ON s.id = f.song_id
+	JOIN groups g ON g.id = s.group_id
+	LEFT JOIN (
+		SELECT song_id, AVG(rating)::FLOAT8 AS average, COUNT(*) AS count FROM song_ratings GROUP BY song_id
+	) r ON r.song_id = s.id
+	LEFT JOIN song_ratings ur ON ur.song_id = s.id AND ur.user_id = f.user_id
+	WHERE f.user_id = $1
+	ORDER BY f.created_at DESC, s.id
+	OFFSET $2`
+
+	args := []interface{}{userID, offset}
+
+	if limit > 0 {
+		q += " LIMIT $3"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+	defer rows.Close()
+
+	favorites := []models.FavoriteSong{}
+
+	for rows.Next() {
+		var song models.FavoriteSong
+
+		err := rows.Scan(&song.SongID, &song.SongName, &song.GroupID, &song.GroupName, &song.Link,
+			&song.AverageRating, &song.RatingsCount, &song.UserRating, &song.FavoritedAt)
+		if err != nil {
+			return nil, e.Wrap(fn, err)
+		}
+
+		favorites = append(favorites, song)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return favorites, nil
+}
+
+// RateSong sets the rating of the user, rating the song again replaces it.
+func (s *Storage) RateSong(ctx context.Context, userID int64, songID int, rating int) (*models.RatingResponse, error) {
+	const fn = "psql.RateSong"
+
+	q := `
+	INSERT INTO song_ratings (user_id, song_id, rating)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id, song_id) DO UPDATE SET rating = EXCLUDED.rating, updated_at = NOW();`
+
+	if _, err := s.db.ExecContext(ctx, q, userID, songID, rating); err != nil {
+		return nil, e.Wrap(fn, songForeignKeyErr(err))
+	}
+
+	res, err := s.songRating(ctx, userID, songID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return res, nil
+}
+
+func (s *Storage) DeleteRating(ctx context.Context, userID int64, songID int) (*models.RatingResponse, error) {
+	const fn = "psql.DeleteRating"
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM song_ratings WHERE user_id = $1 AND song_id = $2;`, userID, songID); err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	res, err := s.songRating(ctx, userID, songID)
+	if err != nil {
+		return nil, e.Wrap(fn, err)
+	}
+
+	return res, nil
+}
+
+func (s *Storage) songRating(ctx context.Context, userID int64, songID int) (*models.RatingResponse, error) {
+	q := `
+	SELECT
+		COALESCE((SELECT rating FROM song_ratings WHERE user_id = $1 AND song_id = $2), 0),
+		COALESCE(AVG(rating)::FLOAT8, 0),
+		COUNT(*)
+	FROM song_ratings
+	WHERE song_id = $2;`
+
+	res := models.RatingResponse{SongID: songID}
+
+	if err := s.db.QueryRowContext(ctx, q, userID, songID).Scan(&res.Rating, &res.AverageRating, &res.RatingsCount); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// songForeignKeyErr turns a foreign key violation on song_id into storage.ErrSongNotFound.
+func songForeignKeyErr(err error) error {
+	var pqErr *pq.Error
+
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return storage.ErrSongNotFound
+	}
+
+	return err
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"errors"
+	"strings"
 	"test_task/internal/models"
 	"time"
 )
@@ -14,23 +15,229 @@ type Storage interface {
 	SongExists(ctx context.Context, SongName string, GroupID int64) (int64, bool, error)
 	DeleteSong(ctx context.Context, songID int) error
 	GetSongText(ctx context.Context, songID int64) (*models.SongTextResp, error)
-	GetLibrary(ctx context.Context, filters *GetLibraryFilters) (map[int64]*models.Group, error)
+	GetLibrary(ctx context.Context, filters *GetLibraryFilters) ([]models.Group, error)
 	UpdateSong(ctx context.Context, songID int, songInfo *SongInfo) error
+	GetSong(ctx context.Context, songID int) (*SongInfo, error)
+
+	SongsToRefresh(ctx context.Context, refreshedBefore, incompleteBefore time.Time, limit int) ([]int, error)
+	MarkRefreshFailed(ctx context.Context, songID int) error
+	ApplySongChanges(ctx context.Context, songID int, changes []models.SongChange) error
+	GetSongChanges(ctx context.Context, songID int) ([]models.SongChange, error)
+
+	GetSongLinks(ctx context.Context, songID int) ([]models.SongLink, error)
+	CreateSongLink(ctx context.Context, songID int, link *models.SongLink) (*models.SongLink, error)
+	UpdateSongLink(ctx context.Context, songID int, linkID int64, update *SongLinkUpdate) (*models.SongLink, error)
+	DeleteSongLink(ctx context.Context, songID int, linkID int64) error
+
+	LinksToCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]LinkToCheck, error)
+	SetLinkStatus(ctx context.Context, songID int, linkID int64, link, status string) error
+	GetBrokenLinks(ctx context.Context, statuses []string, offset, limit int) ([]models.BrokenLink, error)
+
+	SaveJob(ctx context.Context, songID int64) (int64, error)
+	GetJob(ctx context.Context, jobID int64) (*models.Job, error)
+	ClaimJob(ctx context.Context, jobID int64) (*models.Job, bool, error)
+	UpdateJob(ctx context.Context, jobID int64, status, errMsg string) error
+	PendingJobs(ctx context.Context, updatedBefore time.Time) ([]int64, error)
+	ResetRunningJobs(ctx context.Context) error
+
+	EnsureUser(ctx context.Context, subject string) (*models.User, error)
+	GetUserBySubject(ctx context.Context, subject string) (*models.User, error)
+	AddFavorite(ctx context.Context, userID int64, songID int) error
+	RemoveFavorite(ctx context.Context, userID int64, songID int) error
+	GetFavorites(ctx context.Context, userID int64, offset, limit int) ([]models.FavoriteSong, error)
+	RateSong(ctx context.Context, userID int64, songID int, rating int) (*models.RatingResponse, error)
+	DeleteRating(ctx context.Context, userID int64, songID int) (*models.RatingResponse, error)
+
+	CreatePlaylist(ctx context.Context, userID int64, name, description string) (*models.Playlist, error)
+	GetPlaylist(ctx context.Context, playlistID int64) (*models.Playlist, error)
+	GetUserPlaylists(ctx context.Context, userID int64) ([]models.Playlist, error)
+	UpdatePlaylist(ctx context.Context, playlistID int64, update *PlaylistUpdate) (*models.Playlist, error)
+	DeletePlaylist(ctx context.Context, playlistID int64) error
+	DuplicatePlaylist(ctx context.Context, playlistID, userID int64, name string) (*models.Playlist, error)
+	AddPlaylistSong(ctx context.Context, playlistID int64, songID int, position int) (*models.Playlist, error)
+	RemovePlaylistSong(ctx context.Context, playlistID int64, songID int) (*models.Playlist, error)
+	ReorderPlaylist(ctx context.Context, playlistID int64, songIDs []int64) (*models.Playlist, error)
+
+	CreateAlbum(ctx context.Context, groupID int64, title string, releaseDate time.Time) (*models.Album, error)
+	GetAlbum(ctx context.Context, albumID int64) (*models.Album, error)
+	GetAlbums(ctx context.Context, groupID int64) ([]models.Album, error)
+	UpdateAlbum(ctx context.Context, albumID int64, update *AlbumUpdate) (*models.Album, error)
+	DeleteAlbum(ctx context.Context, albumID int64) error
+
+	CreateArtist(ctx context.Context, name string) (*models.Artist, error)
+	GetArtist(ctx context.Context, artistID int64) (*models.Artist, error)
+	SearchArtists(ctx context.Context, name string, offset, limit int) ([]models.Artist, error)
+	UpdateArtist(ctx context.Context, artistID int64, name string) (*models.Artist, error)
+	DeleteArtist(ctx context.Context, artistID int64) error
+	GetGroupMembers(ctx context.Context, groupID int64) ([]models.GroupMember, error)
+	SetGroupMember(ctx context.Context, groupID, artistID int64, joinedYear, leftYear int) error
+	RemoveGroupMember(ctx context.Context, groupID, artistID int64) error
+	GetSongCredits(ctx context.Context, songID int) ([]models.SongCredit, error)
+	AddSongCredit(ctx context.Context, songID int, artistID int64, role string) error
+	RemoveSongCredit(ctx context.Context, songID int, artistID int64, role string) error
+
+	GetTags(ctx context.Context, kind string) ([]models.Tag, error)
+	GetSongTags(ctx context.Context, songID int) (*models.SongTagsResponse, error)
+	SetSongTags(ctx context.Context, songID int, tags *models.TagSet) error
+	GetGroupTags(ctx context.Context, groupID int64) (*models.GroupTagsResponse, error)
+	SetGroupTags(ctx context.Context, groupID int64, tags *models.TagSet) error
+	GetLibraryFacets(ctx context.Context, filters *GetLibraryFilters) (*models.Facets, error)
+
+	GetAttributeDefinitions(ctx context.Context) ([]models.AttributeDefinition, error)
+	CreateAttributeDefinition(ctx context.Context, definition *models.AttributeDefinition) (*models.AttributeDefinition, error)
+	DeleteAttributeDefinition(ctx context.Context, name string) error
+	GetSongAttributes(ctx context.Context, songID int) (map[string]interface{}, error)
+	UpdateSongAttributes(ctx context.Context, songID int, set map[string]interface{}, remove []string) (map[string]interface{}, error)
+	GetGroupAttributes(ctx context.Context, groupID int64) (map[string]interface{}, error)
+	UpdateGroupAttributes(ctx context.Context, groupID int64, set map[string]interface{}, remove []string) (map[string]interface{}, error)
+
+	GetSongRelations(ctx context.Context, songID int) ([]models.SongRelation, error)
+	GetRelatedSongs(ctx context.Context, songID int, depth int) (*models.RelatedSongsResponse, error)
+	AddSongRelation(ctx context.Context, songID int, relatedSongID int64, relationType string) error
+	RemoveSongRelation(ctx context.Context, songID int, relatedSongID int64, relationType string) error
+
+	CreateAPIKey(ctx context.Context, name, role, prefix, keyHash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID int64) error
+	AuthenticateAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error)
+
+	CreateIdempotencyKey(ctx context.Context, key, requestHash string, expiredBefore time.Time, lease time.Duration) (*IdempotencyRecord, bool, error)
+	SaveIdempotentResponse(ctx context.Context, key string, statusCode int, contentType string, body []byte) error
+	DeleteIdempotencyKey(ctx context.Context, key string) error
 }
 
 var (
 	ErrSongNotFound   = errors.New("song not found")
 	ErrNoFieldsUpdate = errors.New("no fields to update")
+	ErrSongExists     = errors.New("group already has a song with this name")
 	ErrNothingFound   = errors.New("nothing found")
+	ErrJobNotFound    = errors.New("job not found")
+	ErrLinkNotFound   = errors.New("link not found")
+	ErrLinkExists     = errors.New("link already exists")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrUserNotFound   = errors.New("user not found")
+
+	ErrPlaylistNotFound     = errors.New("playlist not found")
+	ErrPlaylistSongExists   = errors.New("song is already in the playlist")
+	ErrPlaylistSongNotFound = errors.New("song is not in the playlist")
+	ErrInvalidOrder         = errors.New("order must list every song of the playlist once")
+
+	ErrAlbumNotFound = errors.New("album not found")
+	ErrAlbumExists   = errors.New("group already has an album with this title")
+	ErrGroupNotFound = errors.New("group not found")
+
+	ErrArtistNotFound = errors.New("artist not found")
+	ErrMemberNotFound = errors.New("artist is not a member of the group")
+	ErrCreditNotFound = errors.New("credit not found")
+
+	ErrAttributeNotFound = errors.New("attribute not found")
+	ErrAttributeExists   = errors.New("attribute already exists")
+
+	ErrRelationNotFound = errors.New("relation not found")
+)
+
+// NormalizeName folds the case and the whitespace of a group or song name like the normalize_name
+// SQL function, names equal after it belong to the same group or song.
+func NormalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+const (
+	SongStatusPending = "pending"
+	SongStatusReady   = "ready"
+	SongStatusFailed  = "failed"
+)
+
+const (
+	ChangeSourceScheduler = "scheduler"
+	ChangeSourceManual    = "manual"
+)
+
+const (
+	LinkStatusUnknown     = "unknown"
+	LinkStatusOK          = "ok"
+	LinkStatusBroken      = "broken"
+	LinkStatusUnreachable = "unreachable"
+	LinkStatusInvalid     = "invalid"
+)
+
+const (
+	LinkTypeVideo     = "video"
+	LinkTypeStreaming = "streaming"
+	LinkTypePurchase  = "purchase"
+	LinkTypeLyrics    = "lyrics"
+	LinkTypeOther     = "other"
+)
+
+const (
+	CreditRoleFeatured   = "featured"
+	CreditRoleSongwriter = "songwriter"
+	CreditRoleProducer   = "producer"
+)
+
+const (
+	TagKindGenre = "genre"
+	TagKindTag   = "tag"
+)
+
+// A song is a cover, remix, live version or sample of the related song.
+const (
+	RelationCoverOf       = "cover_of"
+	RelationRemixOf       = "remix_of"
+	RelationLiveVersionOf = "live_version_of"
+	RelationSampleOf      = "sample_of"
+)
+
+// Covers values of the library filters.
+const (
+	CoversInclude = "include"
+	CoversExclude = "exclude"
+	CoversOnly    = "only"
+)
+
+const (
+	AttributeTypeString  = "string"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+)
+
+// SortByRating orders the library by the average rating, unrated songs go last.
+const SortByRating = "rating"
+
+// BrokenLinkStatuses are the link statuses reported as broken.
+var BrokenLinkStatuses = []string{LinkStatusBroken, LinkStatusUnreachable, LinkStatusInvalid}
+
+const (
+	JobStatusPending = "pending"
+	JobStatusRunning = "running"
+	JobStatusDone    = "done"
+	JobStatusFailed  = "failed"
 )
 
 type SongInfo struct {
-	SongID  int
-	Song    string
-	Date    time.Time
-	Text    string
-	Link    string
-	GroupID int64
+	SongID     int
+	Song       string
+	Date       time.Time
+	Text       string
+	Link       string
+	Platform   string
+	ExternalID string
+	Status     string
+	GroupID    int64
+	GroupName  string
+
+	// Album is the title of an album of the song group, it is created if the group has no such album.
+	// AlbumID sets the album directly, 0 removes the song from its album as TrackNumber 0 clears the number.
+	Album       string
+	AlbumID     *int64
+	TrackNumber *int
+
+	// Duration is in seconds. Zero Duration and BPM or empty Key and Language clear the value.
+	Duration *int
+	BPM      *float64
+	Key      *string
+	Language *string
+	Explicit *bool
 }
 
 type GetLibraryFilters struct {
@@ -43,4 +250,81 @@ type GetLibraryFilters struct {
 	ReleaseDate time.Time
 	SongText    string
 	Link        string
+	LinkStatus  string
+	Platform    string
+
+	// UserID marks the favorites of the user, Favorited keeps only them.
+	UserID    int64
+	Favorited bool
+	SortBy    string
+
+	AlbumID    int64
+	AlbumTitle string
+	// Artist is the name of a group member or a credited artist of the song, case-insensitive.
+	Artist   string
+	ArtistID int64
+	// AlbumsView nests the songs of every group into its albums.
+	AlbumsView bool
+
+	// Genres and Tags keep the songs having all of them, or any of them with TagsMatchAny.
+	// The tags of the group count as the tags of its songs.
+	Genres       []string
+	Tags         []string
+	TagsMatchAny bool
+
+	// The ranges are inclusive, 0 leaves the bound open. Durations are in seconds.
+	DurationMin int
+	DurationMax int
+	BPMMin      float64
+	BPMMax      float64
+	Key         string
+	Language    string
+	Explicit    *bool
+
+	// Attributes keep the songs whose attribute equals the value, an attribute of the song
+	// overrides the same attribute of its group.
+	Attributes map[string]interface{}
+
+	// Covers hides the covers with CoversExclude or keeps only them with CoversOnly.
+	Covers string
+}
+
+// AlbumUpdate holds the album fields to update, a zero ReleaseDate clears the date.
+type AlbumUpdate struct {
+	Title       *string
+	ReleaseDate *time.Time
+}
+
+// PlaylistUpdate holds the playlist fields to update, nil fields are left as is.
+type PlaylistUpdate struct {
+	Name        *string
+	Description *string
+}
+
+// SongLinkUpdate holds the fields of a song link to update, URL is set together with Platform and ExternalID.
+type SongLinkUpdate struct {
+	Type       string
+	Label      *string
+	URL        string
+	Platform   string
+	ExternalID string
+	Primary    *bool
+}
+
+// LinkToCheck is a link for the link checker, LinkID is 0 for the primary link stored in songs.link.
+type LinkToCheck struct {
+	SongID int
+	LinkID int64
+	Link   string
+}
+
+// IdempotencyRecord is the first response to a request with an Idempotency-Key,
+// StatusCode is 0 while the first request is still being processed.
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
 }
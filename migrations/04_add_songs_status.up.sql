@@ -0,0 +1 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'ready';
\ No newline at end of file
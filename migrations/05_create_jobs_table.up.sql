@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS jobs(
+    id         SERIAL PRIMARY KEY,
+    song_id    INTEGER   NOT NULL,
+    status     TEXT      NOT NULL DEFAULT 'pending',
+    attempts   INTEGER   NOT NULL DEFAULT 0,
+    error      TEXT      NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS jobs_status_idx ON jobs(status);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS song_changes;
+ALTER TABLE songs DROP COLUMN IF EXISTS refresh_failed_at;
+ALTER TABLE songs DROP COLUMN IF EXISTS refreshed_at;
\ No newline at end of file
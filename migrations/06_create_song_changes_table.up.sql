@@ -0,0 +1,15 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS refreshed_at TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS refresh_failed_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS song_changes(
+    id         SERIAL PRIMARY KEY,
+    song_id    INTEGER   NOT NULL,
+    field      TEXT      NOT NULL,
+    old_value  TEXT      NOT NULL,
+    new_value  TEXT      NOT NULL,
+    source     TEXT      NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS song_changes_song_id_idx ON song_changes(song_id);
+CREATE INDEX IF NOT EXISTS songs_refreshed_at_idx ON songs(refreshed_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS songs_group_id_song_key;
+DROP INDEX IF EXISTS groups_group_name_key;
+DROP FUNCTION IF EXISTS normalize_name(TEXT);
\ No newline at end of file
@@ -0,0 +1,6 @@
+CREATE OR REPLACE FUNCTION normalize_name(name TEXT) RETURNS TEXT
+    LANGUAGE SQL IMMUTABLE
+    AS $$ SELECT lower(btrim(regexp_replace(name, '\s+', ' ', 'g'))) $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS groups_group_name_key ON groups(normalize_name(group_name));
+CREATE UNIQUE INDEX IF NOT EXISTS songs_group_id_song_key ON songs(group_id, normalize_name(song));
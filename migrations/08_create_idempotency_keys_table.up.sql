@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys(
+    key          TEXT PRIMARY KEY,
+    request_hash TEXT      NOT NULL,
+    status_code  INTEGER   NOT NULL DEFAULT 0,
+    content_type TEXT      NOT NULL DEFAULT '',
+    body         BYTEA,
+    locked_until TIMESTAMP NOT NULL DEFAULT NOW(),
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idempotency_keys_created_at_idx ON idempotency_keys(created_at);
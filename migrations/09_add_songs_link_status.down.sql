@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS songs_link_status_idx;
+ALTER TABLE songs DROP COLUMN IF EXISTS link_checked_at;
+ALTER TABLE songs DROP COLUMN IF EXISTS link_status;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS link_status TEXT NOT NULL DEFAULT 'unknown';
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS link_checked_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS songs_link_status_idx ON songs(link_status);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS songs_platform_idx;
+ALTER TABLE songs DROP COLUMN IF EXISTS external_id;
+ALTER TABLE songs DROP COLUMN IF EXISTS platform;
\ No newline at end of file
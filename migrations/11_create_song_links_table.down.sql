@@ -0,0 +1 @@
+DROP TABLE IF EXISTS song_links;
\ No newline at end of file
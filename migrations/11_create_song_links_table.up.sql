@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS song_links(
+    id              SERIAL PRIMARY KEY,
+    song_id         INTEGER   NOT NULL,
+    type            TEXT      NOT NULL DEFAULT 'other',
+    label           TEXT      NOT NULL DEFAULT '',
+    url             TEXT      NOT NULL,
+    platform        TEXT      NOT NULL DEFAULT '',
+    external_id     TEXT      NOT NULL DEFAULT '',
+    is_primary      BOOLEAN   NOT NULL DEFAULT FALSE,
+    link_status     TEXT      NOT NULL DEFAULT 'unknown',
+    link_checked_at TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE,
+    UNIQUE (song_id, url)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS song_links_primary_idx ON song_links(song_id) WHERE is_primary;
+CREATE INDEX IF NOT EXISTS song_links_link_status_idx ON song_links(link_status);
+
+INSERT INTO song_links (song_id, url, platform, external_id, is_primary)
+SELECT id, link, platform, external_id, TRUE
+FROM songs
+WHERE link <> '' AND link <> 'NULL'
+ON CONFLICT DO NOTHING;
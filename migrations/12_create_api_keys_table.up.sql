@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS api_keys(
+    id           SERIAL PRIMARY KEY,
+    name         TEXT      NOT NULL,
+    role         TEXT      NOT NULL,
+    prefix       TEXT      NOT NULL,
+    key_hash     TEXT      NOT NULL UNIQUE,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP,
+    revoked_at   TIMESTAMP
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS song_ratings;
+DROP TABLE IF EXISTS favorites;
+DROP TABLE IF EXISTS users;
\ No newline at end of file
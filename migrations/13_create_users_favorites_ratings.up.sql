@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS users(
+    id         SERIAL PRIMARY KEY,
+    subject    TEXT      NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS favorites(
+    user_id    INTEGER   NOT NULL,
+    song_id    INTEGER   NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, song_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS song_ratings(
+    user_id    INTEGER   NOT NULL,
+    song_id    INTEGER   NOT NULL,
+    rating     SMALLINT  NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, song_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS song_ratings_song_id_idx ON song_ratings(song_id);
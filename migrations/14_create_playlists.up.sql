@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS playlists(
+    id          SERIAL PRIMARY KEY,
+    user_id     INTEGER   NOT NULL,
+    name        TEXT      NOT NULL,
+    description TEXT      NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS playlists_user_id_idx ON playlists(user_id);
+
+-- positions start from 1 and have no gaps, the unique constraint is checked
+-- at commit so that reordering can move the songs one by one.
+CREATE TABLE IF NOT EXISTS playlist_songs(
+    playlist_id INTEGER   NOT NULL,
+    song_id     INTEGER   NOT NULL,
+    position    INTEGER   NOT NULL CHECK (position > 0),
+    added_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (playlist_id, song_id),
+    CONSTRAINT playlist_songs_position_key UNIQUE (playlist_id, position) DEFERRABLE INITIALLY DEFERRED,
+    FOREIGN KEY (playlist_id) REFERENCES playlists (id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS playlist_songs_song_id_idx ON playlist_songs(song_id);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS albums(
+    id           SERIAL PRIMARY KEY,
+    group_id     INTEGER   NOT NULL,
+    title        TEXT      NOT NULL,
+    release_date DATE,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (group_id, title),
+    FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE
+);
+
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS album_id INTEGER REFERENCES albums (id) ON DELETE SET NULL;
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS track_number INTEGER CHECK (track_number > 0);
+CREATE INDEX IF NOT EXISTS songs_album_id_idx ON songs(album_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS song_credits;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS artists;
\ No newline at end of file
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS artists(
+    id         SERIAL PRIMARY KEY,
+    name       TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS artists_name_idx ON artists(LOWER(name));
+
+CREATE TABLE IF NOT EXISTS group_members(
+    group_id    INTEGER  NOT NULL,
+    artist_id   INTEGER  NOT NULL,
+    joined_year SMALLINT,
+    left_year   SMALLINT,
+    PRIMARY KEY (group_id, artist_id),
+    CHECK (left_year IS NULL OR joined_year IS NULL OR left_year >= joined_year),
+    FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE,
+    FOREIGN KEY (artist_id) REFERENCES artists (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS group_members_artist_id_idx ON group_members(artist_id);
+
+CREATE TABLE IF NOT EXISTS song_credits(
+    song_id   INTEGER NOT NULL,
+    artist_id INTEGER NOT NULL,
+    role      TEXT    NOT NULL CHECK (role IN ('featured', 'songwriter', 'producer')),
+    PRIMARY KEY (song_id, artist_id, role),
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE,
+    FOREIGN KEY (artist_id) REFERENCES artists (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS song_credits_artist_id_idx ON song_credits(artist_id);
@@ -0,0 +1,4 @@
+DROP VIEW IF EXISTS song_tag_links;
+DROP TABLE IF EXISTS group_tags;
+DROP TABLE IF EXISTS song_tags;
+DROP TABLE IF EXISTS tags;
\ No newline at end of file
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS tags(
+    id   SERIAL PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('genre', 'tag')),
+    name TEXT NOT NULL,
+    UNIQUE (kind, name)
+);
+
+CREATE TABLE IF NOT EXISTS song_tags(
+    song_id INTEGER NOT NULL,
+    tag_id  INTEGER NOT NULL,
+    PRIMARY KEY (song_id, tag_id),
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS song_tags_tag_id_idx ON song_tags(tag_id);
+
+CREATE TABLE IF NOT EXISTS group_tags(
+    group_id INTEGER NOT NULL,
+    tag_id   INTEGER NOT NULL,
+    PRIMARY KEY (group_id, tag_id),
+    FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS group_tags_tag_id_idx ON group_tags(tag_id);
+
+-- songs inherit the tags of their group
+CREATE OR REPLACE VIEW song_tag_links AS
+SELECT song_id, tag_id FROM song_tags
+UNION
+SELECT s.id, gt.tag_id FROM group_tags gt JOIN songs s ON s.group_id = gt.group_id;
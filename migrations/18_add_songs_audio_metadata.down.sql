@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS songs_duration_idx;
+DROP INDEX IF EXISTS songs_bpm_idx;
+ALTER TABLE songs DROP COLUMN IF EXISTS explicit;
+ALTER TABLE songs DROP COLUMN IF EXISTS language;
+ALTER TABLE songs DROP COLUMN IF EXISTS musical_key;
+ALTER TABLE songs DROP COLUMN IF EXISTS bpm;
+ALTER TABLE songs DROP COLUMN IF EXISTS duration;
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS duration INTEGER CHECK (duration > 0);
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS bpm NUMERIC(5, 1) CHECK (bpm > 0);
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS musical_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT '';
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS explicit BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX IF NOT EXISTS songs_bpm_idx ON songs(bpm);
+CREATE INDEX IF NOT EXISTS songs_duration_idx ON songs(duration);
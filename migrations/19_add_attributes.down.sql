@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS groups_attributes_idx;
+DROP INDEX IF EXISTS songs_attributes_idx;
+ALTER TABLE groups DROP COLUMN IF EXISTS attributes;
+ALTER TABLE songs DROP COLUMN IF EXISTS attributes;
+DROP TABLE IF EXISTS attribute_definitions;
\ No newline at end of file
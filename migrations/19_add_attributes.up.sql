@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS attribute_definitions(
+    name        TEXT PRIMARY KEY,
+    type        TEXT      NOT NULL CHECK (type IN ('string', 'number', 'boolean')),
+    description TEXT      NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS songs_attributes_idx ON songs USING GIN (attributes);
+CREATE INDEX IF NOT EXISTS groups_attributes_idx ON groups USING GIN (attributes);
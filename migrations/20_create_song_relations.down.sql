@@ -0,0 +1 @@
+DROP TABLE IF EXISTS song_relations;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- song_id is a cover, remix, live version or sample of related_song_id
+CREATE TABLE IF NOT EXISTS song_relations(
+    song_id         INTEGER   NOT NULL,
+    related_song_id INTEGER   NOT NULL,
+    type            TEXT      NOT NULL CHECK (type IN ('cover_of', 'remix_of', 'live_version_of', 'sample_of')),
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (song_id, related_song_id, type),
+    CHECK (song_id <> related_song_id),
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE,
+    FOREIGN KEY (related_song_id) REFERENCES songs (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS song_relations_related_song_id_idx ON song_relations(related_song_id);
@@ -0,0 +1,188 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Unix(1700000000, 0)
+
+func encode(t *testing.T, v any) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, secret []byte, alg string, claims map[string]any) string {
+	t.Helper()
+
+	signed := encode(t, map[string]string{"alg": alg, "typ": "JWT"}) + "." + encode(t, claims)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, claims map[string]any) string {
+	t.Helper()
+
+	signed := encode(t, map[string]string{"alg": "RS256", "typ": "JWT"}) + "." + encode(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":  "user-1",
+		"role": "editor",
+		"exp":  testNow.Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerify(t *testing.T) {
+	secret := []byte("secret")
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PUBLIC KEY",
+		Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey),
+	})
+
+	expired := validClaims()
+	expired["exp"] = testNow.Add(-time.Minute).Unix()
+
+	missingExp := validClaims()
+	delete(missingExp, "exp")
+
+	none := encode(t, map[string]string{"alg": "none", "typ": "JWT"}) + "." + encode(t, validClaims()) + "."
+
+	// the payload is swapped for one with another role, the signature is kept
+	escalated := validClaims()
+	escalated["role"] = "admin"
+
+	tampered := strings.Split(signHS256(t, secret, "HS256", validClaims()), ".")
+	tampered[1] = encode(t, escalated)
+
+	tests := []struct {
+		name    string
+		opts    Options
+		token   string
+		wantErr error
+	}{
+		{
+			name:  "valid HS256",
+			opts:  Options{HMACSecret: secret},
+			token: signHS256(t, secret, "HS256", validClaims()),
+		},
+		{
+			name:  "valid RS256",
+			opts:  Options{RSAPublicKey: &rsaKey.PublicKey},
+			token: signRS256(t, rsaKey, validClaims()),
+		},
+		{
+			name:    "alg none",
+			opts:    Options{HMACSecret: secret, RSAPublicKey: &rsaKey.PublicKey},
+			token:   none,
+			wantErr: ErrUnsupportedAlg,
+		},
+		{
+			name:    "RS256 swapped to HS256 signed with the public key",
+			opts:    Options{RSAPublicKey: &rsaKey.PublicKey},
+			token:   signHS256(t, publicPEM, "HS256", validClaims()),
+			wantErr: ErrUnsupportedAlg,
+		},
+		{
+			name:    "HS256 signed with the public key when both keys are configured",
+			opts:    Options{HMACSecret: secret, RSAPublicKey: &rsaKey.PublicKey},
+			token:   signHS256(t, publicPEM, "HS256", validClaims()),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "expired",
+			opts:    Options{HMACSecret: secret},
+			token:   signHS256(t, secret, "HS256", expired),
+			wantErr: ErrExpired,
+		},
+		{
+			name:  "expired within leeway",
+			opts:  Options{HMACSecret: secret, Leeway: 2 * time.Minute},
+			token: signHS256(t, secret, "HS256", expired),
+		},
+		{
+			name:    "missing exp",
+			opts:    Options{HMACSecret: secret},
+			token:   signHS256(t, secret, "HS256", missingExp),
+			wantErr: ErrInvalidClaims,
+		},
+		{
+			name:    "bad signature",
+			opts:    Options{HMACSecret: secret},
+			token:   strings.Join(tampered, "."),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "wrong secret",
+			opts:    Options{HMACSecret: []byte("other")},
+			token:   signHS256(t, secret, "HS256", validClaims()),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "malformed",
+			opts:    Options{HMACSecret: secret},
+			token:   "not.a-token",
+			wantErr: ErrMalformed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVerifier(tt.opts)
+			v.now = func() time.Time { return testNow }
+
+			claims, err := v.Verify(tt.token)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			if claims.Subject != "user-1" || claims.Role != "editor" {
+				t.Errorf("Verify() claims = %+v", claims)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheEviction(t *testing.T) {
+	c := New[string, int](2)
+
+	c.Set("a", 1, time.Minute)
+	c.Set("b", 2, time.Minute)
+
+	// a becomes the most recently used, so b is evicted
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %v, %v, want 1, true", v, ok)
+	}
+
+	c.Set("c", 3, time.Minute)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("b was not evicted")
+	}
+
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if v, ok := c.Get(key); !ok || v != want {
+			t.Errorf("Get(%s) = %v, %v, want %v, true", key, v, ok, want)
+		}
+	}
+
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
+
+func TestCacheUpdate(t *testing.T) {
+	c := New[string, int](2)
+
+	c.Set("a", 1, time.Minute)
+	c.Set("b", 2, time.Minute)
+	c.Set("a", 10, time.Minute)
+	c.Set("c", 3, time.Minute)
+
+	if v, ok := c.Get("a"); !ok || v != 10 {
+		t.Errorf("Get(a) = %v, %v, want 10, true", v, ok)
+	}
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("b was not evicted")
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	c := New[string, int](10)
+
+	c.Set("short", 1, 10*time.Millisecond)
+	c.Set("long", 2, time.Minute)
+
+	if _, ok := c.Get("short"); !ok {
+		t.Fatal("short expired too early")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := c.Get("short"); ok {
+		t.Error("short did not expire")
+	}
+
+	if v, ok := c.Get("long"); !ok || v != 2 {
+		t.Errorf("Get(long) = %v, %v, want 2, true", v, ok)
+	}
+
+	if c.Len() != 1 {
+		t.Errorf("Len() = %d, want the expired entry dropped", c.Len())
+	}
+}
+
+func TestCacheDeleteAndPurge(t *testing.T) {
+	c := New[int, string](0)
+
+	for i := 0; i < 5; i++ {
+		c.Set(i, "v", time.Minute)
+	}
+
+	c.Delete(0)
+
+	if _, ok := c.Get(0); ok {
+		t.Error("0 was not deleted")
+	}
+
+	if n := c.Purge(); n != 4 {
+		t.Errorf("Purge() = %d, want 4", n)
+	}
+
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", c.Len())
+	}
+}
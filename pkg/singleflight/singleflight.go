@@ -0,0 +1,68 @@
+package singleflight
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrPanicked is returned to the callers waiting for a call that panicked.
+var ErrPanicked = errors.New("singleflight: call panicked")
+
+type call[T any] struct {
+	wg  sync.WaitGroup
+	val T
+	err error
+}
+
+// Group coalesces concurrent calls with the same key: while a call is in flight,
+// callers with the same key wait for it and get its result. The zero value is ready to use.
+type Group[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*call[T]
+}
+
+// Do runs fn once for all concurrent callers with the key, shared is true for the callers
+// that waited for the call of another one. If fn panics, the panic goes on in the caller
+// that ran it and the waiting callers get ErrPanicked.
+func (g *Group[T]) Do(key string, fn func() (T, error)) (v T, shared bool, err error) {
+	g.mu.Lock()
+
+	if g.calls == nil {
+		g.calls = make(map[string]*call[T])
+	}
+
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+
+		c.wg.Wait()
+
+		return c.val, true, c.err
+	}
+
+	c := &call[T]{}
+	c.wg.Add(1)
+	g.calls[key] = c
+
+	g.mu.Unlock()
+
+	defer func() {
+		r := recover()
+		if r != nil {
+			c.err = ErrPanicked
+		}
+
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+
+		c.wg.Done()
+
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	c.val, c.err = fn()
+
+	return c.val, false, c.err
+}
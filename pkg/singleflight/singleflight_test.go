@@ -0,0 +1,121 @@
+package singleflight
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDoCoalesces(t *testing.T) {
+	var (
+		g       Group[int]
+		calls   atomic.Int32
+		wg      sync.WaitGroup
+		shared  atomic.Int32
+		release = make(chan struct{})
+	)
+
+	const callers = 10
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			v, isShared, err := g.Do("key", func() (int, error) {
+				calls.Add(1)
+				<-release
+
+				return 42, nil
+			})
+			if err != nil || v != 42 {
+				t.Errorf("Do() = %v, %v, want 42, nil", v, err)
+			}
+
+			if isShared {
+				shared.Add(1)
+			}
+		}()
+	}
+
+	// let the callers line up behind the first one
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("fn ran %d times, want once", calls.Load())
+	}
+
+	if shared.Load() != callers-1 {
+		t.Errorf("shared reported by %d callers, want %d", shared.Load(), callers-1)
+	}
+}
+
+func TestDoDifferentKeys(t *testing.T) {
+	var g Group[string]
+
+	for _, key := range []string{"a", "b"} {
+		v, shared, err := g.Do(key, func() (string, error) { return key, nil })
+		if err != nil || v != key || shared {
+			t.Errorf("Do(%s) = %v, %v, %v", key, v, shared, err)
+		}
+	}
+}
+
+func TestDoError(t *testing.T) {
+	var g Group[int]
+
+	want := errors.New("failed")
+
+	if _, _, err := g.Do("key", func() (int, error) { return 0, want }); !errors.Is(err, want) {
+		t.Errorf("Do() error = %v, want %v", err, want)
+	}
+
+	// the failed call is forgotten, the next one runs again
+	if v, _, err := g.Do("key", func() (int, error) { return 1, nil }); err != nil || v != 1 {
+		t.Errorf("Do() = %v, %v, want 1, nil", v, err)
+	}
+}
+
+func TestDoPanic(t *testing.T) {
+	var g Group[int]
+
+	started := make(chan struct{})
+	waiting := make(chan error)
+
+	go func() {
+		<-started
+
+		_, _, err := g.Do("key", func() (int, error) { return 0, nil })
+		waiting <- err
+	}()
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recovered %v, want the panic of fn", r)
+			}
+		}()
+
+		g.Do("key", func() (int, error) {
+			close(started)
+			// give the waiter time to join the call
+			time.Sleep(50 * time.Millisecond)
+
+			panic("boom")
+		})
+	}()
+
+	if err := <-waiting; !errors.Is(err, ErrPanicked) {
+		t.Errorf("waiter error = %v, want %v", err, ErrPanicked)
+	}
+
+	// the panicked call is forgotten
+	if v, _, err := g.Do("key", func() (int, error) { return 1, nil }); err != nil || v != 1 {
+		t.Errorf("Do() = %v, %v, want 1, nil", v, err)
+	}
+}
@@ -0,0 +1,202 @@
+package songlink
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	PlatformYouTube    = "youtube"
+	PlatformSoundCloud = "soundcloud"
+	PlatformBandcamp   = "bandcamp"
+	PlatformSpotify    = "spotify"
+	PlatformAppleMusic = "apple_music"
+	PlatformDeezer     = "deezer"
+)
+
+// Platforms lists the recognized platforms.
+var Platforms = []string{
+	PlatformYouTube,
+	PlatformSoundCloud,
+	PlatformBandcamp,
+	PlatformSpotify,
+	PlatformAppleMusic,
+	PlatformDeezer,
+}
+
+var (
+	youTubeID = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	spotifyID = regexp.MustCompile(`^[A-Za-z0-9]{22}$`)
+	numericID = regexp.MustCompile(`^[0-9]+$`)
+	slug      = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+)
+
+// platform recognizes links of one platform. parse returns the canonical link and the id
+// of the song on the platform, or false if the link does not point to a song.
+type platform struct {
+	name  string
+	hosts []string
+	// suffix matches all subdomains of the host, e.g. "bandcamp.com" matches "artist.bandcamp.com"
+	suffix string
+	parse  func(u *url.URL) (string, string, bool)
+}
+
+var platforms = []platform{
+	{
+		name: PlatformYouTube,
+		hosts: []string{
+			"youtube.com", "www.youtube.com", "m.youtube.com", "music.youtube.com",
+			"youtu.be", "youtube-nocookie.com", "www.youtube-nocookie.com",
+		},
+		parse: parseYouTube,
+	},
+	{
+		name:  PlatformSoundCloud,
+		hosts: []string{"soundcloud.com", "www.soundcloud.com", "m.soundcloud.com", "on.soundcloud.com"},
+		parse: parseSoundCloud,
+	},
+	{
+		name:   PlatformBandcamp,
+		suffix: "bandcamp.com",
+		parse:  parseBandcamp,
+	},
+	{
+		name:  PlatformSpotify,
+		hosts: []string{"open.spotify.com"},
+		parse: parseSpotify,
+	},
+	{
+		name:  PlatformAppleMusic,
+		hosts: []string{"music.apple.com", "itunes.apple.com"},
+		parse: parseAppleMusic,
+	},
+	{
+		name:  PlatformDeezer,
+		hosts: []string{"deezer.com", "www.deezer.com"},
+		parse: parseDeezer,
+	},
+}
+
+func (p platform) matchHost(host string) bool {
+	for _, h := range p.hosts {
+		if host == h {
+			return true
+		}
+	}
+
+	return p.suffix != "" && (host == p.suffix || strings.HasSuffix(host, "."+p.suffix))
+}
+
+func pathParts(u *url.URL) []string {
+	return strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+}
+
+// parseYouTube handles watch?v=ID, youtu.be/ID, /embed/ID, /shorts/ID, /live/ID and /v/ID.
+func parseYouTube(u *url.URL) (string, string, bool) {
+	parts := pathParts(u)
+
+	var id string
+
+	switch {
+	case u.Hostname() == "youtu.be" && len(parts) == 1:
+		id = parts[0]
+	case len(parts) == 1 && parts[0] == "watch":
+		id = u.Query().Get("v")
+	case len(parts) == 2 && (parts[0] == "embed" || parts[0] == "shorts" || parts[0] == "live" || parts[0] == "v"):
+		id = parts[1]
+	}
+
+	if !youTubeID.MatchString(id) {
+		return "", "", false
+	}
+
+	return "https://www.youtube.com/watch?v=" + id, id, true
+}
+
+// parseSoundCloud handles soundcloud.com/artist/track, the id is "artist/track".
+func parseSoundCloud(u *url.URL) (string, string, bool) {
+	parts := pathParts(u)
+
+	if len(parts) != 2 || !slug.MatchString(parts[0]) || !slug.MatchString(parts[1]) {
+		return "", "", false
+	}
+
+	id := strings.ToLower(parts[0] + "/" + parts[1])
+
+	return "https://soundcloud.com/" + id, id, true
+}
+
+// parseBandcamp handles artist.bandcamp.com/track/name, the id is "artist/name".
+func parseBandcamp(u *url.URL) (string, string, bool) {
+	artist, ok := strings.CutSuffix(u.Hostname(), ".bandcamp.com")
+	if !ok || artist == "www" || strings.Contains(artist, ".") {
+		return "", "", false
+	}
+
+	parts := pathParts(u)
+
+	if len(parts) != 2 || parts[0] != "track" || !slug.MatchString(parts[1]) {
+		return "", "", false
+	}
+
+	return "https://" + artist + ".bandcamp.com/track/" + parts[1], artist + "/" + parts[1], true
+}
+
+// parseSpotify handles open.spotify.com/track/ID with an optional /intl-xx/ prefix.
+func parseSpotify(u *url.URL) (string, string, bool) {
+	parts := pathParts(u)
+
+	if len(parts) == 3 && strings.HasPrefix(parts[0], "intl-") {
+		parts = parts[1:]
+	}
+
+	if len(parts) != 2 || parts[0] != "track" || !spotifyID.MatchString(parts[1]) {
+		return "", "", false
+	}
+
+	return "https://open.spotify.com/track/" + parts[1], parts[1], true
+}
+
+// parseAppleMusic handles music.apple.com/cc/album/name/ALBUM?i=TRACK and music.apple.com/cc/song/name/TRACK.
+func parseAppleMusic(u *url.URL) (string, string, bool) {
+	parts := pathParts(u)
+
+	if len(parts) < 3 {
+		return "", "", false
+	}
+
+	country, kind, last := parts[0], parts[1], parts[len(parts)-1]
+
+	var id string
+
+	switch kind {
+	case "album":
+		id = u.Query().Get("i")
+	case "song":
+		id = last
+	}
+
+	id = strings.TrimPrefix(id, "id")
+
+	if !numericID.MatchString(id) {
+		return "", "", false
+	}
+
+	return "https://music.apple.com/" + strings.ToLower(country) + "/song/" + id, id, true
+}
+
+// parseDeezer handles deezer.com/track/ID with an optional language prefix.
+func parseDeezer(u *url.URL) (string, string, bool) {
+	parts := pathParts(u)
+
+	if len(parts) == 3 {
+		parts = parts[1:]
+	}
+
+	if len(parts) != 2 || parts[0] != "track" || !numericID.MatchString(parts[1]) {
+		return "", "", false
+	}
+
+	return "https://www.deezer.com/track/" + parts[1], parts[1], true
+}
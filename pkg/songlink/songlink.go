@@ -0,0 +1,187 @@
+package songlink
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidURL = errors.New("invalid url")
+
+// PlatformOther is set for valid links of platforms that are not recognized.
+const PlatformOther = "other"
+
+// trackingParams are query parameters that only identify where a link was shared,
+// the ones ending with "_" are prefixes.
+var trackingParams = []string{
+	"utm_", "fbclid", "gclid", "dclid", "yclid", "msclkid", "igshid", "mc_cid", "mc_eid",
+	"_ga", "si", "feature",
+}
+
+type Info struct {
+	URL        string
+	Platform   string
+	ExternalID string
+}
+
+// Parse canonicalizes the link and recognizes its platform. Links without a scheme are treated as https.
+// Links of the known platforms are rewritten to one canonical form, e.g. youtu.be/ID and
+// m.youtube.com/watch?v=ID both become https://www.youtube.com/watch?v=ID. Other links of the known
+// platforms lose default ports, fragments and tracking parameters. Links of other hosts only lose
+// tracking parameters and get the scheme and the host lowercased, the rest is kept as is.
+func Parse(raw string) (Info, error) {
+	raw = strings.TrimSpace(raw)
+
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := parseURL(raw)
+	if err != nil {
+		return Info{}, err
+	}
+
+	for _, p := range platforms {
+		if !p.matchHost(u.Hostname()) {
+			continue
+		}
+
+		clean(u)
+
+		if canonical, id, ok := p.parse(u); ok {
+			return Info{URL: canonical, Platform: p.name, ExternalID: id}, nil
+		}
+
+		return Info{URL: u.String(), Platform: p.name}, nil
+	}
+
+	return Info{URL: stripTrackingParams(lowerSchemeAndHost(raw)), Platform: PlatformOther}, nil
+}
+
+// Normalize is Parse for links from untrusted sources that must be stored anyway:
+// an invalid link is kept as is, without a platform.
+func Normalize(raw string) Info {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return Info{}
+	}
+
+	info, err := Parse(raw)
+	if err != nil {
+		return Info{URL: raw}
+	}
+
+	return info
+}
+
+// parseURL checks that the link is an http or https url with a host and no user info,
+// the scheme and the host of the result are lowercased.
+func parseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, ErrInvalidURL
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" || u.User != nil {
+		return nil, ErrInvalidURL
+	}
+
+	u.Host = host
+	if port := u.Port(); port != "" {
+		u.Host += ":" + port
+	}
+
+	return u, nil
+}
+
+// clean removes the default port, the fragment, trailing slashes and tracking parameters.
+func clean(u *url.URL) {
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = u.Hostname()
+	}
+
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	if len(u.Path) > 1 {
+		u.Path = strings.TrimRight(u.Path, "/")
+		u.RawPath = ""
+	}
+
+	query := u.Query()
+
+	for key := range query {
+		if isTrackingParam(key) {
+			query.Del(key)
+		}
+	}
+
+	u.RawQuery = query.Encode()
+}
+
+// lowerSchemeAndHost lowercases the scheme and the host of the valid link and keeps the rest byte for byte.
+func lowerSchemeAndHost(raw string) string {
+	i := strings.Index(raw, "://")
+	rest := raw[i+len("://"):]
+
+	end := strings.IndexAny(rest, "/?#")
+	if end < 0 {
+		end = len(rest)
+	}
+
+	return strings.ToLower(raw[:i]) + "://" + strings.ToLower(rest[:end]) + rest[end:]
+}
+
+// stripTrackingParams removes tracking parameters from the query of the link,
+// the other parameters keep their order and encoding.
+func stripTrackingParams(link string) string {
+	end := strings.IndexByte(link, '#')
+	if end < 0 {
+		end = len(link)
+	}
+
+	link, fragment := link[:end], link[end:]
+
+	base, query, ok := strings.Cut(link, "?")
+	if !ok {
+		return link + fragment
+	}
+
+	var kept []string
+
+	for _, param := range strings.Split(query, "&") {
+		key, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+
+		if !isTrackingParam(key) {
+			kept = append(kept, param)
+		}
+	}
+
+	if len(kept) > 0 {
+		base += "?" + strings.Join(kept, "&")
+	}
+
+	return base + fragment
+}
+
+func isTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, param := range trackingParams {
+		if key == param || (strings.HasSuffix(param, "_") && strings.HasPrefix(key, param)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,35 +2,174 @@ package validate
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
+	"syscall"
+	"time"
 )
 
-func Link(ctx context.Context, URL string) bool {
+var (
+	ErrInvalidURL        = errors.New("invalid url")
+	ErrForbiddenAddress  = errors.New("forbidden address")
+	ErrTooManyRedirects  = errors.New("too many redirects")
+	ErrBrokenLink        = errors.New("broken link")
+	ErrUnreachableServer = errors.New("server is unreachable")
+)
+
+// forbiddenPrefixes are ranges not covered by the netip.Addr helpers.
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+type LinkOptions struct {
+	Timeout      time.Duration
+	MaxRedirects int
+	MaxBodySize  int64
+
+	// AllowPrivate disables the address checks, only for local development.
+	AllowPrivate bool
+}
+
+// LinkValidator checks that a link is reachable without letting callers reach internal addresses:
+// every address is checked after DNS resolution, right before connecting, including the redirects.
+type LinkValidator struct {
+	client *http.Client
+	opts   LinkOptions
+}
+
+func NewLinkValidator(opts LinkOptions) *LinkValidator {
+	dialer := &net.Dialer{
+		Timeout: opts.Timeout,
+	}
+
+	if !opts.AllowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			addr, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+
+			if forbidden(addr) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+			}
+
+			return nil
+		}
+	}
+
+	transport := &http.Transport{
+		// a proxy would connect instead of us and skip the address checks
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   opts.Timeout,
+		ResponseHeaderTimeout: opts.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > opts.MaxRedirects {
+				return ErrTooManyRedirects
+			}
+
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("%w: redirect to %s", ErrInvalidURL, req.URL.Scheme)
+			}
 
-	parsedURL, err := url.Parse(URL)
-	if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
-		return false
+			return nil
+		},
+	}
+
+	return &LinkValidator{
+		client: client,
+		opts:   opts,
+	}
+}
+
+// Check sends a HEAD request to the link, falling back to GET if HEAD is not supported.
+// It returns ErrInvalidURL, ErrForbiddenAddress, ErrTooManyRedirects, ErrBrokenLink for 404 and 410
+// or ErrUnreachableServer.
+func (v *LinkValidator) Check(ctx context.Context, rawURL string) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || parsedURL.Host == "" || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return ErrInvalidURL
+	}
+
+	status, err := v.do(ctx, http.MethodHead, rawURL)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = v.do(ctx, http.MethodGet, rawURL)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL, nil)
 	if err != nil {
-		return false
+		for _, target := range []error{ErrForbiddenAddress, ErrTooManyRedirects, ErrInvalidURL} {
+			if errors.Is(err, target) {
+				return target
+			}
+		}
+
+		return fmt.Errorf("%w: %s", ErrUnreachableServer, err)
 	}
-	req.Close = true
 
-	resp, err := http.DefaultClient.Do(req)
-	if resp != nil {
-		defer resp.Body.Close()
+	if status == http.StatusNotFound || status == http.StatusGone {
+		return fmt.Errorf("%w: status %d", ErrBrokenLink, status)
 	}
 
+	return nil
+}
+
+func (v *LinkValidator) do(ctx context.Context, method, rawURL string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
 	if err != nil {
-		return false
+		return 0, ErrInvalidURL
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, v.opts.MaxBodySize))
+
+	return resp.StatusCode, nil
+}
+
+func forbidden(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() {
+		return true
 	}
 
-	if resp.StatusCode == http.StatusNotFound {
-		return false
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
 	}
 
-	return true
+	return false
 }
@@ -0,0 +1,132 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestForbidden(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "127.0.0.1", want: true},
+		{addr: "127.1.2.3", want: true},
+		{addr: "::1", want: true},
+		{addr: "10.1.2.3", want: true},
+		{addr: "172.16.0.1", want: true},
+		{addr: "172.31.255.255", want: true},
+		{addr: "192.168.1.1", want: true},
+		{addr: "169.254.169.254", want: true},
+		{addr: "fe80::1", want: true},
+		{addr: "fc00::1", want: true},
+		{addr: "fd12:3456::1", want: true},
+		{addr: "::ffff:127.0.0.1", want: true},
+		{addr: "::ffff:10.0.0.1", want: true},
+		{addr: "::ffff:169.254.169.254", want: true},
+		{addr: "64:ff9b::a9fe:a9fe", want: true},
+		{addr: "0.0.0.0", want: true},
+		{addr: "::", want: true},
+		{addr: "100.64.0.1", want: true},
+		{addr: "224.0.0.1", want: true},
+		{addr: "8.8.8.8", want: false},
+		{addr: "172.32.0.1", want: false},
+		{addr: "::ffff:8.8.8.8", want: false},
+		{addr: "2606:4700:4700::1111", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := forbidden(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("forbidden(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckForbiddenAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	v := NewLinkValidator(LinkOptions{Timeout: time.Second, MaxRedirects: 5, MaxBodySize: 1 << 10})
+
+	for _, link := range []string{
+		srv.URL,
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.1/",
+		"http://[::ffff:127.0.0.1]:" + port(t, srv.URL) + "/",
+	} {
+		t.Run(link, func(t *testing.T) {
+			if err := v.Check(context.Background(), link); !errors.Is(err, ErrForbiddenAddress) {
+				t.Errorf("Check() error = %v, want %v", err, ErrForbiddenAddress)
+			}
+		})
+	}
+}
+
+func TestCheckRedirectToPrivateAddress(t *testing.T) {
+	private := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer private.Close()
+
+	public := httptest.NewServer(http.RedirectHandler(private.URL+"/admin", http.StatusFound))
+	defer public.Close()
+
+	v := NewLinkValidator(LinkOptions{Timeout: time.Second, MaxRedirects: 5, MaxBodySize: 1 << 10})
+
+	// public.test stands for a public host: only its connections skip the address checks
+	transport := v.client.Transport.(*http.Transport).Clone()
+	dial := transport.DialContext
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if address == "public.test:80" {
+			return (&net.Dialer{}).DialContext(ctx, network, public.Listener.Addr().String())
+		}
+
+		return dial(ctx, network, address)
+	}
+	v.client.Transport = transport
+
+	if err := v.Check(context.Background(), "http://public.test/song"); !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("Check() error = %v, want %v", err, ErrForbiddenAddress)
+	}
+}
+
+func TestCheckAllowPrivate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	v := NewLinkValidator(LinkOptions{Timeout: time.Second, MaxRedirects: 5, MaxBodySize: 1 << 10, AllowPrivate: true})
+
+	if err := v.Check(context.Background(), srv.URL); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+
+	if err := v.Check(context.Background(), srv.URL+"/missing"); !errors.Is(err, ErrBrokenLink) {
+		t.Errorf("Check() error = %v, want %v", err, ErrBrokenLink)
+	}
+
+	if err := v.Check(context.Background(), "ftp://example.com/"); !errors.Is(err, ErrInvalidURL) {
+		t.Errorf("Check() error = %v, want %v", err, ErrInvalidURL)
+	}
+}
+
+func port(t *testing.T, rawURL string) string {
+	t.Helper()
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u.Port()
+}